@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+//ByteSize is a number of bytes that can be configured with units,
+//e.g. "512MiB", "1.5GB" or just "1024"
+//	B
+//	KB, MB, GB, TB, PB   (multiples of 1000)
+//	KiB, MiB, GiB, TiB, PiB (multiples of 1024)
+//units are not case sensitive
+type ByteSize uint64
+
+var byteSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"pb":  1000 * 1000 * 1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit \"%s\" in byte size \"%s\"", s[i:], s)
+	}
+	if !strings.Contains(number, ".") {
+		//whole numbers are exact, also above 2^53
+		n, err := strconv.ParseUint(number, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid byte size \"%s\"", s)
+		}
+		hi, size := bits.Mul64(n, uint64(multiplier))
+		if hi != 0 {
+			return 0, fmt.Errorf("invalid byte size \"%s\" (too large)", s)
+		}
+		return ByteSize(size), nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size \"%s\"", s)
+	}
+	size := f * float64(multiplier)
+	if size >= math.MaxUint64 { //float64(math.MaxUint64) is 2^64
+		return 0, fmt.Errorf("invalid byte size \"%s\" (too large)", s)
+	}
+	if size != math.Trunc(size) {
+		return 0, fmt.Errorf("invalid byte size \"%s\" (not a whole number of bytes)", s)
+	}
+	return ByteSize(size), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+//String uses the largest binary unit that represents the size exactly
+func (b ByteSize) String() string {
+	for _, unit := range []string{"PiB", "TiB", "GiB", "MiB", "KiB"} {
+		multiplier := byteSizeUnits[strings.ToLower(unit)]
+		if b >= ByteSize(multiplier) && uint64(b)%multiplier == 0 {
+			return fmt.Sprintf("%d%s", uint64(b)/multiplier, unit)
+		}
+	}
+	return fmt.Sprintf("%dB", uint64(b))
+}
@@ -0,0 +1,14 @@
+//configctl works with config files outside of Go code, run without arguments for usage
+//values are resolved from env and then the files, as in a service that imports config/source/env
+package main
+
+import (
+	"os"
+
+	"github.com/stewelarend/config/configctl"
+	_ "github.com/stewelarend/config/source/env"
+)
+
+func main() {
+	os.Exit(configctl.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
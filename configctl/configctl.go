@@ -0,0 +1,342 @@
+//Package configctl implements the configctl command to work with config files
+//outside of Go code, see cmd/configctl
+//
+//To validate against the structs registered by your service, build your own
+//configctl that imports the packages that register them:
+//
+//	import _ "github.com/me/myservice/server"
+//	func main() { os.Exit(configctl.Run(os.Args[1:], os.Stdout, os.Stderr)) }
+package configctl
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/configfile"
+)
+
+const usage = `usage: configctl <command> [options] [args]
+
+commands:
+  validate [-schema schema.json] file...     check files against a schema,
+                                             or the schema and structs registered in the binary
+  get <key> [file...]                        resolve a value and print where it came from
+  dump [-format json|yaml|toml|env] [-redact] [-provenance] [-non-default] [file...]
+                                             print the resolved config tree
+  diff <a> <b>                               print the values that differ between two files
+  convert [-to json|yaml|toml|env] <in> [out]
+                                             convert a file to another format
+
+  sample [-format yaml|env] [file]           write a sample config from the registered defaults,
+                                             the format is env if the filename contains .env
+  docs [-format markdown|html]               write reference documentation of the registered config
+
+files are used in the order given, the first file that has a value applies
+`
+
+//Run runs configctl with the command line arguments (without the program name)
+//and returns the exit code: 0 on success, 1 for invalid config or differences, 2 for usage errors
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	commands := map[string]func(args []string, stdout io.Writer) error{
+		"validate": validate,
+		"get":      get,
+		"dump":     dump,
+		"diff":     diff,
+		"convert":  convert,
+		"sample":   sample,
+		"docs":     docs,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command \"%s\"\n%s", args[0], usage)
+		return 2
+	}
+	if err := command(args[1:], stdout); err != nil {
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "%v\n%s", err, usage)
+			return 2
+		}
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+} //Run()
+
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+//addFiles adds the files as config sources
+func addFiles(filenames []string, schema config.Schema) error {
+	for _, filename := range filenames {
+		if err := configfile.AddWithSchema(filename, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validate(args []string, stdout io.Writer) error {
+	flags := newFlagSet("validate")
+	schemaFilename := flags.String("schema", "", "JSON Schema file")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flags.NArg() == 0 {
+		return usageError("validate: missing file")
+	}
+
+	schema := config.JSONSchema()
+	if *schemaFilename != "" {
+		content, err := os.ReadFile(*schemaFilename)
+		if err != nil {
+			return fmt.Errorf("cannot read schema: %v", err)
+		}
+		schema = config.Schema{}
+		if err := json.Unmarshal(content, &schema); err != nil {
+			return fmt.Errorf("cannot read schema from file(%s): %v", *schemaFilename, err)
+		}
+	}
+
+	//check all files before failing, to report all problems
+	failed := []string{}
+	for _, filename := range flags.Args() {
+		if err := configfile.AddWithSchema(filename, schema); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) == 0 && *schemaFilename == "" {
+		if err := config.Validate(); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) == 0 && *schemaFilename != "" {
+		//each file is checked as a layer, check required values in all files together
+		if errs := schema.Check(config.Resolved(), config.CheckText()); len(errs) > 0 {
+			failed = append(failed, errs.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "\n"))
+	}
+	fmt.Fprintln(stdout, "valid")
+	return nil
+} //validate()
+
+func get(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return usageError("get: missing key")
+	}
+	key := args[0]
+	if err := addFiles(args[1:], nil); err != nil {
+		return err
+	}
+	value, err := config.Lookup(key)
+	if err != nil {
+		return err
+	}
+	s := fmt.Sprintf("%v", value)
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		s = string(data)
+	}
+	fmt.Fprintf(stdout, "%s = %s (from %s)\n", key, s, config.SourceOf(key))
+	return nil
+}
+
+func dump(args []string, stdout io.Writer) error {
+	flags := newFlagSet("dump")
+	format := flags.String("format", "json", "output format")
+	redact := flags.Bool("redact", false, "replace secret values with ***")
+	provenance := flags.Bool("provenance", false, "write the source of each value as a comment")
+	nonDefault := flags.Bool("non-default", false, "only values that differ from the defaults")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if err := addFiles(flags.Args(), nil); err != nil {
+		return err
+	}
+	opts := config.ExportOptions{Resolved: true, Provenance: *provenance, NonDefault: *nonDefault}
+	if *redact {
+		opts.Redact = config.SecretWords
+	}
+	return config.Export(stdout, *format, opts)
+}
+
+func diff(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return usageError("diff: expecting two files")
+	}
+	a, err := configfile.Load(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := configfile.Load(args[1])
+	if err != nil {
+		return err
+	}
+	aLeaves, bLeaves := leaves("", a), leaves("", b)
+	keys := []string{}
+	for k := range aLeaves {
+		keys = append(keys, k)
+	}
+	for k := range bLeaves {
+		if _, ok := aLeaves[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	differences := 0
+	for _, k := range keys {
+		av, inA := aLeaves[k]
+		bv, inB := bLeaves[k]
+		switch {
+		case !inB:
+			fmt.Fprintf(stdout, "- %s = %s\n", k, av)
+		case !inA:
+			fmt.Fprintf(stdout, "+ %s = %s\n", k, bv)
+		case av != bv:
+			fmt.Fprintf(stdout, "~ %s = %s -> %s\n", k, av, bv)
+		default:
+			continue
+		}
+		differences++
+	}
+	if differences > 0 {
+		return fmt.Errorf("%d differences", differences)
+	}
+	return nil
+} //diff()
+
+//leaves flattens an object to its leaf values in dotted notation, formatted as JSON
+func leaves(path string, value interface{}) map[string]string {
+	flat := map[string]string{}
+	if obj, ok := value.(map[string]interface{}); ok && (len(obj) > 0 || path == "") {
+		for k, v := range obj {
+			name := config.NewPath(k).String()
+			if path != "" {
+				name = path + "." + name
+			}
+			for leafName, leafValue := range leaves(name, v) {
+				flat[leafName] = leafValue
+			}
+		}
+		return flat
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%v", value))
+	}
+	flat[path] = string(data)
+	return flat
+}
+
+func convert(args []string, stdout io.Writer) error {
+	flags := newFlagSet("convert")
+	to := flags.String("to", "", "output format, default from the output filename")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usageError("convert: expecting input and optional output file")
+	}
+	format := *to
+	if format == "" {
+		if flags.NArg() < 2 {
+			return usageError("convert: specify -to or an output file")
+		}
+		var err error
+		if format, err = formatOf(flags.Arg(1)); err != nil {
+			return err
+		}
+	}
+	value, err := configfile.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		return config.Encode(stdout, value, format)
+	}
+	f, err := os.Create(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := config.Encode(f, value, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+} //convert()
+
+//formatOf returns the format for a filename from its extension
+func formatOf(filename string) (string, error) {
+	switch ext := strings.TrimPrefix(filepath.Ext(filename), "."); ext {
+	case "json", "toml", "env":
+		return ext, nil
+	case "yaml", "yml":
+		return "yaml", nil
+	}
+	return "", fmt.Errorf("unknown format for file(%s) expecting %s", filename, strings.Join(config.Formats, "|"))
+}
+
+func sample(args []string, stdout io.Writer) error {
+	flags := newFlagSet("sample")
+	format := flags.String("format", "yaml", "yaml or env")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	switch flags.NArg() {
+	case 0:
+		return config.WriteSample(stdout, *format)
+	case 1:
+		formatSet := false
+		flags.Visit(func(f *flag.Flag) { formatSet = formatSet || f.Name == "format" })
+		if !formatSet {
+			return config.WriteSampleFile(flags.Arg(0)) //format from the filename
+		}
+		f, err := os.Create(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := config.WriteSample(f, *format); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	return usageError("sample: expecting one output file")
+}
+
+func docs(args []string, stdout io.Writer) error {
+	flags := newFlagSet("docs")
+	format := flags.String("format", "markdown", "markdown or html")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	return config.WriteDocs(stdout, *format)
+}
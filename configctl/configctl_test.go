@@ -0,0 +1,61 @@
+package configctl_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config/configctl"
+)
+
+func TestConfigctl(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	a := write("a.yaml", "ctl:\n  name: web\n  ports: [80, 443]\n  tls:\n    cert: \"a.pem\"\n  routes:\n  - path: /api\n  - path: /web\n")
+	b := write("b.json", `{"ctl":{"name":"api","ports":[80,443],"debug":true}}`)
+	schema := write("schema.json", `{"properties":{"ctl":{"properties":{"name":{"enum":["web","api"]},"debug":{"type":"string"}}}}}`)
+
+	run := func(args ...string) (int, string, string) {
+		stdout, stderr := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+		code := configctl.Run(args, stdout, stderr)
+		return code, stdout.String(), stderr.String()
+	}
+	tests := []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{}, 2, "", "usage: configctl"},
+		{[]string{"unknown"}, 2, "", "unknown command \"unknown\""},
+		{[]string{"convert", "-to", "toml", a}, 0, "[ctl]\nname = \"web\"\nports = [80, 443]\n\n[ctl.tls]\ncert = \"a.pem\"\n\n[[ctl.routes]]\npath = \"/api\"\n\n[[ctl.routes]]\npath = \"/web\"\n", ""},
+		{[]string{"convert", b, "out.txt"}, 1, "", "unknown format"},
+		{[]string{"diff", a, b}, 1, "+ ctl.debug = true\n~ ctl.name = \"web\" -> \"api\"\n- ctl.routes = [{\"path\":\"/api\"},{\"path\":\"/web\"}]\n- ctl.tls.cert = \"a.pem\"\n", "4 differences"},
+		{[]string{"validate", "-schema", schema, a}, 0, "valid\n", ""},
+		{[]string{"validate", "-schema", schema, b}, 1, "", b + ":1:39: ctl.debug: expected string, got (bool)true"},
+		{[]string{"get", "ctl.name", a}, 0, "ctl.name = \"web\" (from " + a + ")\n", ""},
+	}
+	for _, test := range tests {
+		code, stdout, stderr := run(test.args...)
+		if code != test.code || stdout != test.stdout || !strings.Contains(stderr, test.stderr) {
+			t.Errorf("configctl %v: code=%d stdout=%q stderr=%q, expected %d %q %q", test.args, code, stdout, stderr, test.code, test.stdout, test.stderr)
+		}
+	}
+
+	//the format flag also applies to an output file
+	sampleFile := filepath.Join(dir, "sample.txt")
+	if code, _, stderr := run("sample", "-format", "env", sampleFile); code != 0 {
+		t.Fatalf("sample failed: %s", stderr)
+	}
+	if content, err := os.ReadFile(sampleFile); err != nil || !strings.HasPrefix(string(content), "# sample environment") {
+		t.Fatalf("sample file=%q,%v", content, err)
+	}
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/stewelarend/logger"
+)
+
+//DefaultConflict is returned by SetDefault() when the default cannot be set,
+//because a different default for the same name was set before at another place
+//conflicts are also reported by Validate(), as the error of SetDefault() is often ignored
+type DefaultConflict struct {
+	Name     string //name of the default that could not be set
+	Site     string //file:line of the SetDefault() call that failed
+	Existing string //file:line of the SetDefault() call that set the existing default, if known
+	Err      error
+}
+
+func (c *DefaultConflict) Error() string {
+	if c.Existing == "" {
+		return fmt.Sprintf("cannot set default for %s at %s: %v", c.Name, c.Site, c.Err)
+	}
+	return fmt.Sprintf("cannot set default for %s at %s (conflicts with default set at %s): %v", c.Name, c.Site, c.Existing, c.Err)
+}
+
+func (c *DefaultConflict) Unwrap() error {
+	return c.Err
+}
+
+var (
+	conflictsMutex  sync.Mutex
+	conflicts       = []*DefaultConflict{}
+	defaultSites    = map[string]string{} //name -> file:line of SetDefault()
+	panicOnConflict = false
+)
+
+//SetPanicOnConflict makes SetDefault() panic on a conflict rather than return an error,
+//to fail at init time when packages set defaults for the same names
+func SetPanicOnConflict(enabled bool) {
+	conflictsMutex.Lock()
+	defer conflictsMutex.Unlock()
+	panicOnConflict = enabled
+}
+
+//callerSite is the location of a call, e.g. "github.com/me/server/config.go:12"
+//also for calls from init() or closures, which Caller.PackageFile() includes
+//in the package name
+func callerSite(caller logger.Caller) string {
+	return fmt.Sprintf("%s/%s:%d", callerPackage(caller), path.Base(caller.File()), caller.Line())
+}
+
+//setDefaultSite records where the default was set
+//keyed by the canonical path, as searched by defaultSiteOf()
+func setDefaultSite(p Path, site string) {
+	conflictsMutex.Lock()
+	defer conflictsMutex.Unlock()
+	defaultSites[p.String()] = site
+}
+
+//defaultSiteOf returns where the default for name, its parent or a child was set
+func defaultSiteOf(name string) string {
+	p, err := ParsePath(name)
+	if err != nil {
+		return ""
+	}
+	conflictsMutex.Lock()
+	defer conflictsMutex.Unlock()
+	for i := len(p); i > 0; i-- {
+		if site, ok := defaultSites[p[:i].String()]; ok {
+			return site
+		}
+	}
+	for _, n := range sortedKeys(defaultSites) {
+		if strings.HasPrefix(n, name+".") {
+			return defaultSites[n]
+		}
+	}
+	return ""
+}
+
+//addConflict records a conflict and returns it, or panics in panic-on-conflict mode
+func addConflict(c *DefaultConflict) error {
+	conflictsMutex.Lock()
+	defer conflictsMutex.Unlock()
+	conflicts = append(conflicts, c)
+	if panicOnConflict {
+		panic(c.Error())
+	}
+	return c
+}
+
+//conflictErrors returns the recorded conflicts for Validate()
+func conflictErrors() ValidationErrors {
+	conflictsMutex.Lock()
+	defer conflictsMutex.Unlock()
+	errs := ValidationErrors{}
+	for _, c := range conflicts {
+		errs = append(errs, &ValidationError{Path: c.Name, Err: c})
+	}
+	return errs
+}
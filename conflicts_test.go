@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+)
+
+//initSiteLine is the line of the SetDefault() call in init()
+var initSiteLine int
+
+func init() {
+	_, _, initSiteLine, _ = runtime.Caller(0)
+	config.SetDefault("initsite.port", 8000)
+}
+
+func TestDefaultConflictFromInit(t *testing.T) {
+	if site := config.DefaultSiteOf(`"initsite".port`); site != fmt.Sprintf("github.com/stewelarend/config_test/conflicts_test.go:%d", initSiteLine+1) {
+		t.Fatalf("site=%s", site)
+	}
+	if owner := config.OwnerOf(`"initsite".port`); owner != "github.com/stewelarend/config_test" {
+		t.Fatalf("owner=%s", owner)
+	}
+}
+
+func TestDefaultConflicts(t *testing.T) {
+	_, _, line, _ := runtime.Caller(0)
+	config.SetDefault("conflict.http", map[string]interface{}{"port": 8000})
+	err := config.SetDefault("conflict.http.port", 9000)
+
+	site := func(line int) string {
+		return fmt.Sprintf("github.com/stewelarend/config_test/conflicts_test.go:%d", line)
+	}
+	var conflict *config.DefaultConflict
+	if !errors.As(err, &conflict) || conflict.Name != "conflict.http.port" || conflict.Site != site(line+2) || conflict.Existing != site(line+1) {
+		t.Fatalf("wrong conflict: %v", err)
+	}
+
+	//also reported by Validate(), as the error is often ignored
+	found := false
+	var errs config.ValidationErrors
+	if errors.As(config.Validate(), &errs) {
+		for _, e := range errs {
+			if e.Path == "conflict.http.port" && strings.Contains(e.Error(), site(line+1)) && strings.Contains(e.Error(), site(line+2)) {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("conflict not reported by Validate(): %v", errs)
+	}
+
+	//the same default from another place is not a conflict
+	if err := config.SetDefault("conflict.http.port", 8000); errors.As(err, &conflict) {
+		t.Fatalf("same default is a conflict: %v", err)
+	}
+
+	//panic on conflict
+	config.SetPanicOnConflict(true)
+	defer config.SetPanicOnConflict(false)
+
+	//other errors are not conflicts
+	if err := config.SetDefault("conflict..x", 1); !errors.Is(err, config.ErrInvalidName) || errors.As(err, &conflict) {
+		t.Fatalf("expected invalid name, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		//second time from the same place
+		if err := config.SetDefault("conflict.same", i); (i == 1 && err == nil) || errors.As(err, &conflict) {
+			t.Fatalf("expected error without conflict, got %v", err)
+		}
+	}
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprintf("%v", r), "conflict.http") {
+			t.Fatalf("expected panic on conflict, got %v", r)
+		}
+	}()
+	config.SetDefault("conflict.http", 1)
+}
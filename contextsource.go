@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+//IContextSource is the extended source interface for sources that can fail,
+//be slow or be cancelled, e.g. remote sources
+//add it with AddContextSource(), and use ContextSource() to call an ISource
+//through this interface
+type IContextSource interface {
+	//Get a value, the name may use dotted notation for nesting
+	//found=false when the source does not have the value,
+	//err is set only when the source failed
+	Get(ctx context.Context, name string) (value interface{}, found bool, err error)
+
+	//Keys returns the names of the leaf values in dotted notation
+	//that are equal to prefix or nested under it, all keys for prefix=""
+	//returns ErrNotSupported when the source cannot list its keys
+	Keys(ctx context.Context, prefix string) ([]string, error)
+
+	//Close releases the resources of the source, e.g. connections
+	Close() error
+}
+
+//IWatcher is optionally implemented by sources that can report changes,
+//see WatchSources()
+type IWatcher interface {
+	//Watch sends an event for each change until ctx is done,
+	//then closes the channel
+	Watch(ctx context.Context) <-chan Event
+}
+
+//Event is a change reported by a watched source
+type Event struct {
+	Source string   //name of the source
+	Keys   []string //names of the changed values, empty when not known
+	Err    error    //set when the source failed to watch
+}
+
+//AddContextSource adds a context source after those already added
+//it fails with ErrFrozen after Freeze()
+func AddContextSource(s IContextSource, opts ...SourceOption) error {
+	if s == nil {
+		return nil
+	}
+	if adapter, ok := s.(contextSource); ok {
+		return AddSource(adapter.ISource, opts...)
+	}
+	return AddSource(legacySource{s}, opts...)
+}
+
+//ContextSource returns the source as an IContextSource
+//an ISource cannot fail, Keys() uses IKeysSource if implemented else
+//returns ErrNotSupported, and Close() uses io.Closer if implemented
+func ContextSource(s ISource) IContextSource {
+	if legacy, ok := s.(legacySource); ok {
+		return legacy.IContextSource
+	}
+	return contextSource{s}
+}
+
+//CloseSources closes all sources that can be closed
+//it returns the first error but closes all of them
+func CloseSources() error {
+	var firstErr error
+	for _, e := range sourceList() {
+		if err := ContextSource(e.source).Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("cannot close source %s: %w", sourceName(e.source), err)
+		}
+	}
+	return firstErr
+}
+
+//WatchSources sends the events of all sources that implement IWatcher
+//until ctx is done or all of them stopped, then closes the channel
+//values that are already used do not change, the events let the application
+//decide what to do, e.g. restart to use the new config
+func WatchSources(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	wg := sync.WaitGroup{}
+	for _, e := range sourceList() {
+		var watcher IWatcher
+		if legacy, ok := e.source.(legacySource); ok {
+			watcher, _ = legacy.IContextSource.(IWatcher)
+		} else {
+			watcher, _ = e.source.(IWatcher)
+		}
+		if watcher == nil {
+			continue
+		}
+		name := sourceName(e.source)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watched := watcher.Watch(ctx)
+			for event := range watched {
+				event.Source = name
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					//drain until the watcher stops
+					for range watched {
+					}
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+	return events
+} //WatchSources()
+
+//contextSource adapts an ISource to IContextSource
+type contextSource struct {
+	ISource
+}
+
+func (s contextSource) Get(ctx context.Context, name string) (interface{}, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	v, ok := s.ISource.Get(name)
+	return v, ok, nil
+}
+
+func (s contextSource) Keys(ctx context.Context, prefix string) ([]string, error) {
+	keysSource, ok := s.ISource.(IKeysSource)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return keysWithPrefix(keysSource.Keys(), prefix), nil
+}
+
+func (s contextSource) Close() error {
+	if closer, ok := s.ISource.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (s contextSource) String() string {
+	return sourceName(s.ISource)
+}
+
+//legacySource adapts an IContextSource to ISource
+//for the code that does not need a context, see sourceEntry.keys() for its keys
+type legacySource struct {
+	IContextSource
+}
+
+func (s legacySource) Get(name string) (interface{}, bool) {
+	v, ok, err := s.IContextSource.Get(context.Background(), name)
+	if err != nil {
+		log.Errorf("source %s failed to get %s: %v", sourceName(s), name, err)
+		return nil, false
+	}
+	return v, ok
+}
+
+func (s legacySource) String() string {
+	if stringer, ok := s.IContextSource.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", s.IContextSource)
+}
+
+//keysWithPrefix returns the keys equal to prefix or nested under it
+func keysWithPrefix(keys []string, prefix string) []string {
+	if prefix == "" {
+		return keys
+	}
+	matching := []string{}
+	for _, key := range keys {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			matching = append(matching, key)
+		}
+	}
+	return matching
+}
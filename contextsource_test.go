@@ -0,0 +1,160 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+)
+
+//mapSource is a context source with flat keys
+//"ctxsrc.broken" fails and the context is checked on each call
+type mapSource struct {
+	values  map[string]interface{}
+	keysErr error
+	closed  bool
+}
+
+func (s *mapSource) Get(ctx context.Context, name string) (interface{}, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	if name == "ctxsrc.broken" {
+		return nil, false, errors.New("connection refused")
+	}
+	v, ok := s.values[name]
+	return v, ok, nil
+}
+
+func (s *mapSource) Keys(ctx context.Context, prefix string) ([]string, error) {
+	if s.keysErr != nil {
+		return nil, s.keysErr
+	}
+	keys := []string{}
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *mapSource) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *mapSource) String() string { return "map" }
+
+func TestContextSource(t *testing.T) {
+	s := &mapSource{values: map[string]interface{}{"ctxsrc.port": 8080, "ctxsrc.unused": 1}}
+	if err := config.AddContextSource(s); err != nil {
+		t.Fatalf("add source failed: %v", err)
+	}
+	if port, ok := config.GetInt("ctxsrc.port"); !ok || port != 8080 {
+		t.Fatalf("port=%v,%v", port, ok)
+	}
+	if config.SourceOf("ctxsrc.port") != "map" {
+		t.Fatalf("source=%s", config.SourceOf("ctxsrc.port"))
+	}
+
+	//errors from the source are reported
+	_, err := config.Lookup("ctxsrc.broken")
+	var sourceErr *config.SourceError
+	if !errors.As(err, &sourceErr) || sourceErr.Source != "map" || sourceErr.Err.Error() != "connection refused" {
+		t.Fatalf("expected source error, got %v", err)
+	}
+
+	//its keys are used to report unused config
+	found := false
+	for _, unused := range config.UnusedKeys() {
+		if unused.Key == "ctxsrc.unused" && unused.Source == "map" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("ctxsrc.unused not reported")
+	}
+
+	//key errors fail Freeze() and are not taken as no keys
+	s.keysErr = errors.New("connection refused")
+	t.Cleanup(config.Unfreeze)
+	err = config.Freeze()
+	if err == nil || !strings.Contains(err.Error(), "cannot list keys: connection refused") {
+		t.Fatalf("expected freeze to fail, got %v", err)
+	}
+	if config.Frozen() {
+		t.Fatalf("frozen after failed freeze")
+	}
+	s.keysErr = config.ErrNotSupported
+	for _, unused := range config.UnusedKeys() {
+		if unused.Source == "map" {
+			t.Fatalf("unexpected unused %s from map", unused.Key)
+		}
+	}
+	s.keysErr = nil
+
+	//ISource works through the adapter
+	values := config.NewValues("adapted", map[string]interface{}{"a": map[string]interface{}{"b": 1, "c": 2}, "ab": 3})
+	adapted := config.ContextSource(values)
+	if v, ok, err := adapted.Get(context.Background(), "a.b"); err != nil || !ok || v != 1 {
+		t.Fatalf("a.b=%v,%v,%v", v, ok, err)
+	}
+	if keys, err := adapted.Keys(context.Background(), "a"); err != nil || !reflect.DeepEqual(keys, []string{"a.b", "a.c"}) {
+		t.Fatalf("keys=%v,%v", keys, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := adapted.Get(ctx, "a.b"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if _, err := config.ContextSource(noKeysSource{}).Keys(context.Background(), ""); !errors.Is(err, config.ErrNotSupported) {
+		t.Fatalf("expected not supported, got %v", err)
+	}
+
+	if err := config.CloseSources(); err != nil || !s.closed {
+		t.Fatalf("close=%v,%v", err, s.closed)
+	}
+}
+
+type noKeysSource struct{}
+
+func (noKeysSource) Get(name string) (interface{}, bool) { return nil, false }
+
+//watchSource reports a change of "watched.port" and stops when ctx is done
+type watchSource struct {
+	noKeysSource
+}
+
+func (watchSource) Watch(ctx context.Context) <-chan config.Event {
+	events := make(chan config.Event)
+	go func() {
+		defer close(events)
+		select {
+		case events <- config.Event{Keys: []string{"watched.port"}}:
+		case <-ctx.Done():
+			return
+		}
+		<-ctx.Done()
+	}()
+	return events
+}
+
+func (watchSource) String() string { return "watched" }
+
+func TestWatchSources(t *testing.T) {
+	if err := config.AddSource(watchSource{}); err != nil {
+		t.Fatalf("add source failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := config.WatchSources(ctx)
+	event := <-events
+	if event.Source != "watched" || !reflect.DeepEqual(event.Keys, []string{"watched.port"}) || event.Err != nil {
+		t.Fatalf("event=%+v", event)
+	}
+	cancel()
+	if event, ok := <-events; ok {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
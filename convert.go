@@ -0,0 +1,250 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	urlType             = reflect.TypeOf(&url.URL{})
+	ipType              = reflect.TypeOf(net.IP{})
+	ipNetType           = reflect.TypeOf(&net.IPNet{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+//convert a config value to type t, see decode() for the rules
+func convert(value interface{}, t reflect.Type) (interface{}, error) {
+	out := reflect.New(t).Elem()
+	if err := newDecoder().decode("", value, out); err != nil {
+		return nil, err
+	}
+	return out.Interface(), nil
+}
+
+//isScalar is true for types that convertScalar() parses,
+//even if they are structs, slices or pointers in Go
+func isScalar(t reflect.Type) bool {
+	switch t {
+	case durationType, timeType, urlType, ipType, ipNetType:
+		return true
+	}
+	return false
+}
+
+//convertScalar converts a single value, e.g. from a string or another number type
+//parse rules are the same for all types:
+//	- strings are parsed, e.g. "8000" for int, "30s" for time.Duration
+//	- numbers convert between int/uint/float only if the value fits, e.g. 9000.0 -> int 9000
+//	- types that implement encoding.TextUnmarshaler are parsed from strings
+func convertScalar(value interface{}, t reflect.Type) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+	s, isString := value.(string)
+	switch t {
+	case durationType:
+		if isString {
+			d, err := time.ParseDuration(strings.TrimSpace(s))
+			if err != nil {
+				return nil, expected(t, value)
+			}
+			return d, nil
+		}
+		//numbers are nanoseconds, same as time.Duration
+		i, err := convertScalar(value, reflect.TypeOf(int64(0)))
+		if err != nil {
+			return nil, expected(t, value)
+		}
+		return time.Duration(i.(int64)), nil
+
+	case timeType:
+		if !isString {
+			return nil, expected(t, value)
+		}
+		tm, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+		if err != nil {
+			return nil, &TypeError{Want: "RFC3339 time", Got: value}
+		}
+		return tm, nil
+
+	case urlType:
+		if !isString {
+			return nil, expected(t, value)
+		}
+		u, err := url.Parse(strings.TrimSpace(s))
+		if err != nil || u.Scheme == "" {
+			return nil, &TypeError{Want: "URL with scheme", Got: value}
+		}
+		return u, nil
+
+	case ipType:
+		if !isString {
+			return nil, expected(t, value)
+		}
+		ip := net.ParseIP(strings.TrimSpace(s))
+		if ip == nil {
+			return nil, &TypeError{Want: "IP address", Got: value}
+		}
+		return ip, nil
+
+	case ipNetType:
+		if !isString {
+			return nil, expected(t, value)
+		}
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			return nil, &TypeError{Want: "CIDR", Got: value}
+		}
+		return ipNet, nil
+	}
+
+	//types that parse themselves from text, e.g. ByteSize
+	if isString && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		ptrValue := reflect.New(t)
+		if err := ptrValue.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return nil, &TypeError{Want: t.String(), Got: value, Err: err}
+		}
+		return ptrValue.Elem().Interface(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		switch rv.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			return nil, expected(t, value)
+		}
+		return reflect.ValueOf(fmt.Sprintf("%v", value)).Convert(t).Interface(), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if rv.Uint() > math.MaxInt64 {
+				return nil, expected(t, value)
+			}
+			i = int64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			if f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 {
+				return nil, expected(t, value)
+			}
+			i = int64(f)
+		case reflect.String:
+			var err error
+			if i, err = strconv.ParseInt(strings.TrimSpace(s), 10, 64); err != nil {
+				//also allow "1e3" and "9000.0"
+				f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+				if err != nil || f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 {
+					return nil, expected(t, value)
+				}
+				i = int64(f)
+			}
+		default:
+			return nil, expected(t, value)
+		}
+		out := reflect.New(t).Elem()
+		if out.OverflowInt(i) {
+			return nil, expected(t, value)
+		}
+		out.SetInt(i)
+		return out.Interface(), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if rv.Int() < 0 {
+				return nil, expected(t, value)
+			}
+			u = uint64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u = rv.Uint()
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+				return nil, expected(t, value)
+			}
+			u = uint64(f)
+		case reflect.String:
+			var err error
+			if u, err = strconv.ParseUint(strings.TrimSpace(s), 10, 64); err != nil {
+				//also allow "1e3" and "9000.0"
+				f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+				if err != nil || f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+					return nil, expected(t, value)
+				}
+				u = uint64(f)
+			}
+		default:
+			return nil, expected(t, value)
+		}
+		out := reflect.New(t).Elem()
+		if out.OverflowUint(u) {
+			return nil, expected(t, value)
+		}
+		out.SetUint(u)
+		return out.Interface(), nil
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f = float64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f = float64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			f = rv.Float()
+		case reflect.String:
+			var err error
+			if f, err = strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+				return nil, expected(t, value)
+			}
+		default:
+			return nil, expected(t, value)
+		}
+		out := reflect.New(t).Elem()
+		if out.OverflowFloat(f) {
+			return nil, expected(t, value)
+		}
+		out.SetFloat(f)
+		return out.Interface(), nil
+
+	case reflect.Bool:
+		switch rv.Kind() {
+		case reflect.Bool:
+			return rv.Convert(t).Interface(), nil
+		case reflect.String:
+			b, err := strconv.ParseBool(strings.TrimSpace(s))
+			if err != nil {
+				return nil, expected(t, value)
+			}
+			return reflect.ValueOf(b).Convert(t).Interface(), nil
+		}
+	}
+	return nil, expected(t, value)
+} //convertScalar()
+
+//expected is the error when a value cannot convert to type t
+func expected(t reflect.Type, value interface{}) error {
+	return &TypeError{Want: t.String(), Got: value}
+}
+
+//describe a value for use in error messages
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(v)
+	}
+	return fmt.Sprintf("(%T)%v", value, value)
+}
@@ -0,0 +1,477 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+//IDecoder is implemented by types that decode themselves from a config value
+//(with a pointer receiver), e.g. to accept either a string or an object:
+//	func (l *LogLevel) DecodeConfig(value interface{}) error {...}
+type IDecoder interface {
+	DecodeConfig(value interface{}) error
+}
+
+var decoderType = reflect.TypeOf((*IDecoder)(nil)).Elem()
+
+//Option changes how a value is decoded in GetStruct(), GetNamedStruct() and Bind()
+type Option func(d *decoder)
+
+//WithStrict overrides the global strict mode (see SetStrict()) for one call
+func WithStrict(strict bool) Option {
+	return func(d *decoder) {
+		d.strict = strict
+	}
+}
+
+//strict is 1 when enabled, read without locking so that decoding
+//after Freeze() does not take a global lock
+var strict int32
+
+//SetStrict enables strict mode for all structs:
+//keys in an object that are not fields of the struct and not in the defaults
+//then fail with an *UnknownKeyError, e.g. to detect "prot" misspelled for "port"
+func SetStrict(enabled bool) {
+	value := int32(0)
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&strict, value)
+}
+
+func strictMode() bool {
+	return atomic.LoadInt32(&strict) == 1
+}
+
+//decoder holds the options for one decode
+type decoder struct {
+	strict  bool
+	collect bool            //continue after struct field errors, see collectErrors()
+	errs    []error         //collected errors
+	failed  map[string]bool //keys of fields that failed to decode
+}
+
+//collectErrors makes the decoder continue with the next struct field
+//after an error, so that all errors can be reported, e.g. by Validate()
+func collectErrors() Option {
+	return func(d *decoder) {
+		d.collect = true
+	}
+}
+
+//fail records an error for a struct field when collecting errors,
+//else returns it to stop decoding
+func (d *decoder) fail(fieldKey string, err error) error {
+	if !d.collect {
+		return err
+	}
+	d.errs = append(d.errs, err)
+	if d.failed == nil {
+		d.failed = map[string]bool{}
+	}
+	d.failed[fieldKey] = true
+	return nil
+}
+
+func newDecoder(opts ...Option) *decoder {
+	d := &decoder{strict: strictMode()}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+//decode a config value into out, which must be settable
+//key is the full name of the value, used in errors, e.g. "server.http.port"
+//the rules are:
+//	- types with a decoder registered with RegisterDecoder() use that decoder
+//	- types that implement IDecoder decode themselves
+//	- values that are already of the type are used as is
+//	- scalars convert with convertScalar(), e.g. "8000" into an int
+//	- objects decode into structs using json names and struct tags (see fieldInfo)
+//	- lists decode into slices, also from a comma separated string, e.g. "a,b,c"
+//	- objects decode into maps, also from a string of name=value pairs, e.g. "a=1,b=2"
+//	- pointers are allocated and the value decoded into the element
+func (d *decoder) decode(key string, value interface{}, out reflect.Value) error {
+	t := out.Type()
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			out.Set(reflect.Zero(t))
+			return nil
+		}
+	}
+
+	if decoder, ok := registeredDecoder(t); ok {
+		return d.decodeRegistered(key, decoder, value, out)
+	}
+	if reflect.PtrTo(t).Implements(decoderType) {
+		ptrValue := reflect.New(t)
+		if err := ptrValue.Interface().(IDecoder).DecodeConfig(value); err != nil {
+			return &TypeError{Key: key, Want: t.String(), Got: value, Err: err}
+		}
+		out.Set(ptrValue.Elem())
+		return nil
+	}
+
+	if value == nil {
+		switch t.Kind() {
+		case reflect.Struct:
+			if t != timeType {
+				//nil is an empty struct, e.g. {"server":{"http":null}} to use http with defaults
+				return d.decodeStruct(key, map[string]interface{}{}, out)
+			}
+		}
+		return withKey(expected(t, value), key)
+	}
+
+	if reflect.TypeOf(value).AssignableTo(t) {
+		out.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	if isScalar(t) {
+		return d.decodeScalar(key, value, out)
+	}
+	if _, isString := value.(string); isString && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return d.decodeScalar(key, value, out)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elemValue := reflect.New(t.Elem())
+		if err := d.decode(key, value, elemValue.Elem()); err != nil {
+			return err
+		}
+		out.Set(elemValue)
+		return nil
+
+	case reflect.Interface:
+		//value does not implement the interface, else it would be assignable
+		return withKey(expected(t, value), key)
+
+	case reflect.Struct:
+		return d.decodeStruct(key, value, out)
+
+	case reflect.Slice, reflect.Array:
+		return d.decodeList(key, value, out)
+
+	case reflect.Map:
+		return d.decodeMap(key, value, out)
+	}
+	return d.decodeScalar(key, value, out)
+} //decode()
+
+//hasDecoder is true when the type has a registered decoder or implements IDecoder
+func hasDecoder(t reflect.Type) bool {
+	if _, ok := registeredDecoder(t); ok {
+		return true
+	}
+	return reflect.PtrTo(t).Implements(decoderType)
+}
+
+func (d *decoder) decodeScalar(key string, value interface{}, out reflect.Value) error {
+	v, err := convertScalar(value, out.Type())
+	if err != nil {
+		return withKey(err, key)
+	}
+	out.Set(reflect.ValueOf(v))
+	return nil
+}
+
+//decodeStruct decodes an object into a struct:
+//env tags override the value, default tags apply to fields not in the object,
+//and fields with required tags must be in the object or have a default
+func (d *decoder) decodeStruct(key string, value interface{}, out reflect.Value) error {
+	t := out.Type()
+	obj, ok := toObject(value)
+	if !ok {
+		if value == nil || reflect.TypeOf(value).Kind() != reflect.Struct {
+			return withKey(expected(t, value), key)
+		}
+		//another struct type, e.g. a default set with SetDefault()
+		structObj, err := structValue(reflect.ValueOf(value))
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		obj, _ = structObj.(map[string]interface{})
+	}
+
+	newValue := reflect.New(t).Elem()
+	for _, f := range structFields(t) {
+		fieldKey := joinKey(key, quoteSegment(f.name))
+		fieldValue, err := fieldByIndex(newValue, f.index)
+		if err != nil {
+			return fmt.Errorf("%s: %v", fieldKey, err)
+		}
+
+		if f.env != "" {
+			if s := os.Getenv(f.env); s != "" {
+				if err := d.decode(fieldKey, s, fieldValue); err != nil {
+					var typeErr *TypeError
+					if errors.As(err, &typeErr) {
+						typeErr.Source = "env " + f.env
+					}
+					if err := d.fail(fieldKey, err); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		}
+
+		raw, present := objectField(obj, f.name)
+		if !present || raw == nil {
+			if f.hasDefault {
+				if err := d.decode(fieldKey, f.defaultValue, fieldValue); err != nil {
+					if err := d.fail(fieldKey, fmt.Errorf("invalid default: %v", err)); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if f.required {
+				if err := d.fail(fieldKey, &ValidationError{Path: fieldKey, Err: fmt.Errorf("is required but %w", ErrNotDefined)}); err != nil {
+					return err
+				}
+				continue
+			}
+			if !present {
+				//apply defaults inside nested structs, but leave pointers nil
+				if fieldValue.Kind() == reflect.Struct && !isScalar(fieldValue.Type()) && !hasDecoder(fieldValue.Type()) {
+					if err := d.decode(fieldKey, nil, fieldValue); err != nil {
+						if err := d.fail(fieldKey, err); err != nil {
+							return err
+						}
+					}
+				}
+				continue
+			}
+		}
+		if err := d.decode(fieldKey, raw, fieldValue); err != nil {
+			if err := d.fail(fieldKey, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.strict {
+		unknown := unknownKeys(key, obj, t)
+		if !d.collect && len(unknown) > 1 {
+			//report all of them, not only the first
+			errs := ValidationErrors{}
+			for _, err := range unknown {
+				errs = append(errs, validateErrors(key, err)...)
+			}
+			return errs
+		}
+		for _, err := range unknown {
+			if err := d.fail(err.Key, err); err != nil {
+				return err
+			}
+		}
+	}
+	out.Set(newValue)
+	return nil
+} //decodeStruct()
+
+//unknownKeys returns an error for each key in obj (sorted by name)
+//that is not a field of struct type t and not defined in the defaults
+func unknownKeys(key string, obj map[string]interface{}, t reflect.Type) []*UnknownKeyError {
+	errs := []*UnknownKeyError{}
+	fields := structFields(t)
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		known := false
+		for _, f := range fields {
+			if strings.EqualFold(n, f.name) {
+				known = true
+				break
+			}
+		}
+		if known {
+			continue
+		}
+		fieldKey := joinKey(key, quoteSegment(n))
+		if _, ok := defaults.Get(fieldKey); ok {
+			continue
+		}
+		errs = append(errs, &UnknownKeyError{Key: fieldKey, Source: sourceWith(fieldKey)})
+	}
+	return errs
+} //unknownKeys()
+
+//toObject returns value as an object if it is a map with string keys
+//maps with other keys, e.g. map[interface{}]interface{} from YAML, are converted
+func toObject(value interface{}) (map[string]interface{}, bool) {
+	if obj, ok := value.(map[string]interface{}); ok {
+		return obj, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map {
+		return nil, false
+	}
+	obj := map[string]interface{}{}
+	iter := rv.MapRange()
+	for iter.Next() {
+		obj[fmt.Sprintf("%v", iter.Key().Interface())] = iter.Value().Interface()
+	}
+	return obj, true
+}
+
+//objectField returns the named field from obj,
+//matching the name case insensitive if not found, as encoding/json does
+func objectField(obj map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := obj[name]; ok {
+		return v, true
+	}
+	for n, v := range obj {
+		if strings.EqualFold(n, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (d *decoder) decodeList(key string, value interface{}, out reflect.Value) error {
+	t := out.Type()
+	if s, isString := value.(string); isString {
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			out.Set(reflect.ValueOf([]byte(s)).Convert(t))
+			return nil
+		}
+		//comma separated list, e.g. from ENV
+		items := []interface{}{}
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value = items
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return withKey(expected(t, value), key)
+	}
+	newValue := reflect.New(t).Elem()
+	if t.Kind() == reflect.Slice {
+		newValue.Set(reflect.MakeSlice(t, rv.Len(), rv.Len()))
+	} else if rv.Len() != t.Len() {
+		return &TypeError{Key: key, Want: t.String(), Got: value, Err: fmt.Errorf("expected %d items", t.Len())}
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := d.decode(fmt.Sprintf("%s[%d]", key, i), rv.Index(i).Interface(), newValue.Index(i)); err != nil {
+			return err
+		}
+	}
+	out.Set(newValue)
+	return nil
+} //decodeList()
+
+func (d *decoder) decodeMap(key string, value interface{}, out reflect.Value) error {
+	t := out.Type()
+	if s, isString := value.(string); isString && t.Key().Kind() == reflect.String {
+		//list of name=value pairs, e.g. from ENV
+		obj := map[string]interface{}{}
+		for _, pair := range strings.Split(s, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			nv := strings.SplitN(pair, "=", 2)
+			if len(nv) != 2 {
+				return &TypeError{Key: key, Want: "name=value pairs", Got: value}
+			}
+			obj[strings.TrimSpace(nv[0])] = strings.TrimSpace(nv[1])
+		}
+		value = obj
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map {
+		return withKey(expected(t, value), key)
+	}
+	newValue := reflect.MakeMapWithSize(t, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		itemKey := joinKey(key, quoteSegment(fmt.Sprintf("%v", iter.Key().Interface())))
+		k := reflect.New(t.Key()).Elem()
+		if err := d.decode(itemKey, iter.Key().Interface(), k); err != nil {
+			return err
+		}
+		v := reflect.New(t.Elem()).Elem()
+		if err := d.decode(itemKey, iter.Value().Interface(), v); err != nil {
+			return err
+		}
+		newValue.SetMapIndex(k, v)
+	}
+	out.Set(newValue)
+	return nil
+} //decodeMap()
+
+//getAs retrieves a named value and converts it to type t, see bind()
+func getAs(name string, t reflect.Type, opts ...Option) (interface{}, error) {
+	value, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return bind(name, value, t, opts...)
+} //getAs()
+
+//bind decodes a value into type t
+//and validates it and all nested values, see validate()
+func bind(name string, value interface{}, t reflect.Type, opts ...Option) (interface{}, error) {
+	ptrValue := reflect.New(t)
+	d := newDecoder(opts...)
+	if err := d.decode(name, value, ptrValue.Elem()); err != nil {
+		return nil, withSource(err, name)
+	}
+	if !d.collect {
+		if errs := validate(name, ptrValue.Elem()); len(errs) > 0 {
+			return nil, errs
+		}
+		return ptrValue.Elem().Interface(), nil
+	}
+
+	//report decode errors with the other problems,
+	//but do not validate the fields that failed to decode
+	errs := ValidationErrors{}
+	for _, err := range d.errs {
+		errs = append(errs, validateErrors(name, withSource(err, name))...)
+	}
+	for _, err := range validate(name, ptrValue.Elem()) {
+		if !d.failedPath(err.Path) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return ptrValue.Elem().Interface(), nil
+} //bind()
+
+//withSource sets the source of a TypeError that does not have one
+func withSource(err error, name string) error {
+	var typeErr *TypeError
+	if errors.As(err, &typeErr) && typeErr.Source == "" {
+		typeErr.Source = sourceOf(name)
+	}
+	return err
+}
+
+//failedPath is true when path is a field that failed to decode, or under it
+func (d *decoder) failedPath(path string) bool {
+	for fieldKey := range d.failed {
+		if path == fieldKey || strings.HasPrefix(path, fieldKey+".") || strings.HasPrefix(path, fieldKey+"[") {
+			return true
+		}
+	}
+	return false
+}
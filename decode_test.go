@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+type decodeLogLevel int
+
+//accepts "debug"|"info"|"error" or a number
+func (l *decodeLogLevel) DecodeConfig(value interface{}) error {
+	switch fmt.Sprintf("%v", value) {
+	case "debug", "0":
+		*l = 0
+	case "info", "1":
+		*l = 1
+	case "error", "2":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown log level")
+	}
+	return nil
+}
+
+type decodeCommonConfig struct {
+	Name     string         `json:"name"`
+	LogLevel decodeLogLevel `json:"log_level"`
+}
+
+type decodeServerConfig struct {
+	decodeCommonConfig
+	Port     int               `json:"port"`
+	Timeout  time.Duration     `json:"timeout"`
+	MaxSize  config.ByteSize   `json:"max_size"`
+	Hosts    []string          `json:"hosts"`
+	Labels   map[string]string `json:"labels"`
+	Backends []struct {
+		Address string `json:"address"`
+		Weight  int    `json:"weight" default:"1"`
+	} `json:"backends"`
+	TLS *struct {
+		Cert string `json:"cert"`
+	} `json:"tls"`
+}
+
+func TestDecode(t *testing.T) {
+	static.Add(map[string]interface{}{
+		"decode": map[string]interface{}{
+			"ok": map[string]interface{}{
+				"name":      "test",
+				"log_level": "info",
+				"port":      "9000",
+				"timeout":   "1m",
+				"max_size":  "1MiB",
+				"hosts":     "a,b",
+				"labels":    map[string]interface{}{"x": 1},
+				"backends": []interface{}{
+					map[string]interface{}{"address": "one"},
+					map[string]interface{}{"address": "two", "weight": 2.0},
+				},
+			},
+			"badport":    map[string]interface{}{"port": "abc"},
+			"badbackend": map[string]interface{}{"backends": []interface{}{map[string]interface{}{"weight": "x"}}},
+			"badlevel":   map[string]interface{}{"log_level": "warn"},
+		},
+	})
+
+	c, err := config.Bind[decodeServerConfig]("decode.ok")
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+	if c.Name != "test" || c.LogLevel != 1 || c.Port != 9000 || c.Timeout != time.Minute || c.MaxSize != 1<<20 ||
+		len(c.Hosts) != 2 || c.Labels["x"] != "1" || len(c.Backends) != 2 || c.Backends[0].Weight != 1 || c.Backends[1].Weight != 2 || c.TLS != nil {
+		t.Fatalf("wrong value: %+v", c)
+	}
+
+	for name, expectedErr := range map[string]string{
+		"decode.badport":    `decode.badport.port: expected int, got "abc" (from static)`,
+		"decode.badbackend": `decode.badbackend.backends[0].weight: expected int, got "x" (from static)`,
+		"decode.badlevel":   `decode.badlevel.log_level: expected config_test.decodeLogLevel, got "warn": unknown log level (from static)`,
+	} {
+		if _, err := config.Bind[decodeServerConfig](name); err == nil || !strings.Contains(err.Error(), expectedErr) {
+			t.Fatalf("%s: wrong error: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+//DecoderFunc decodes a config value into a registered type
+//it must return a value of that type, or an error describing why the value is invalid
+type DecoderFunc func(value interface{}) (interface{}, error)
+
+//decoders holds a map[reflect.Type]DecoderFunc that is replaced, not changed,
+//when a decoder is registered, so that it is read without locking
+var (
+	decodersMutex sync.Mutex //serialises RegisterDecoder()
+	decoders      atomic.Value
+)
+
+func init() {
+	decoders.Store(map[reflect.Type]DecoderFunc{})
+}
+
+//RegisterDecoder registers a decoder for a type you cannot implement IDecoder on
+//it is used in GetStruct(), named structs and the typed getters, e.g.:
+//	config.RegisterDecoder(reflect.TypeOf(LogLevel(0)), func(value interface{}) (interface{}, error) {
+//		return ParseLogLevel(fmt.Sprintf("%v", value))
+//	})
+//registering a type again replaces the previous decoder
+func RegisterDecoder(t reflect.Type, decoder DecoderFunc) {
+	if t == nil || decoder == nil {
+		panic(fmt.Errorf("cannot register decoder for %v", t))
+	}
+	decodersMutex.Lock()
+	defer decodersMutex.Unlock()
+	current := decoders.Load().(map[reflect.Type]DecoderFunc)
+	updated := make(map[reflect.Type]DecoderFunc, len(current)+1)
+	for currentType, currentDecoder := range current {
+		updated[currentType] = currentDecoder
+	}
+	updated[t] = decoder
+	decoders.Store(updated)
+}
+
+func registeredDecoder(t reflect.Type) (DecoderFunc, bool) {
+	decoder, ok := decoders.Load().(map[reflect.Type]DecoderFunc)[t]
+	return decoder, ok
+}
+
+//decodeRegistered decodes value with the decoder registered for the type of out
+func (d *decoder) decodeRegistered(key string, decoder DecoderFunc, value interface{}, out reflect.Value) error {
+	t := out.Type()
+	v, err := decoder(value)
+	if err != nil {
+		return &TypeError{Key: key, Want: t.String(), Got: value, Err: err}
+	}
+	if v == nil {
+		out.Set(reflect.Zero(t))
+		return nil
+	}
+	if !reflect.TypeOf(v).AssignableTo(t) {
+		return &TypeError{Key: key, Want: t.String(), Got: value, Err: fmt.Errorf("decoder returned %T", v)}
+	}
+	out.Set(reflect.ValueOf(v))
+	return nil
+}
+
+func init() {
+	RegisterDecoder(reflect.TypeOf(&regexp.Regexp{}), func(value interface{}) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expecting a regular expression string")
+		}
+		return regexp.Compile(s)
+	})
+}
@@ -0,0 +1,61 @@
+package config_test
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+type decoderColor struct {
+	R, G, B uint8
+}
+
+type decoderConfig struct {
+	Color   decoderColor   `json:"color"`
+	Pattern *regexp.Regexp `json:"pattern"`
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	config.RegisterDecoder(reflect.TypeOf(decoderColor{}), func(value interface{}) (interface{}, error) {
+		switch value {
+		case "red":
+			return decoderColor{R: 255}, nil
+		case "green":
+			return decoderColor{G: 255}, nil
+		}
+		return nil, fmt.Errorf("unknown color, expecting red|green")
+	})
+	static.Add(map[string]interface{}{
+		"decoders": map[string]interface{}{
+			"ok":         map[string]interface{}{"color": "red", "pattern": "^a+$"},
+			"badcolor":   map[string]interface{}{"color": "blue"},
+			"badpattern": map[string]interface{}{"pattern": "a("},
+			"green":      "green",
+		},
+	})
+
+	c, err := config.Bind[decoderConfig]("decoders.ok")
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+	if c.Color.R != 255 || c.Pattern == nil || !c.Pattern.MatchString("aaa") {
+		t.Fatalf("wrong value: %+v", c)
+	}
+
+	//also used by typed getters
+	if color, ok := config.GetAs[decoderColor]("decoders.green"); !ok || color.G != 255 {
+		t.Fatalf("GetAs->%+v,%v", color, ok)
+	}
+
+	if _, err := config.Bind[decoderConfig]("decoders.badcolor"); err == nil || !strings.Contains(err.Error(), `decoders.badcolor.color: expected config_test.decoderColor, got "blue": unknown color, expecting red|green`) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if _, err := config.Bind[decoderConfig]("decoders.badpattern"); err == nil || !strings.Contains(err.Error(), `decoders.badpattern.pattern: expected *regexp.Regexp, got "a("`) {
+		t.Fatalf("wrong error: %v", err)
+	}
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"reflect"
+
+	"github.com/stewelarend/logger"
 )
 
 var (
@@ -10,12 +13,68 @@ var (
 
 //Set a default value to use if value is not found in any config engine
 //Fails when already defined (which may be from a config engine or default previously set)
+//when another call set a different default, the error is a *DefaultConflict
+//naming where both defaults were set, see SetPanicOnConflict()
+//or ErrFrozen after Freeze()
 func SetDefault(name string, defaultValue interface{}) error {
+	return setDefault(name, defaultValue, logger.GetCaller(2))
+}
+
+//setDefault sets the default and records the package of the caller that set it
+func setDefault(name string, defaultValue interface{}, caller logger.Caller) error {
+	if Frozen() {
+		return fmt.Errorf("cannot set default for %s: %w", name, ErrFrozen)
+	}
+	p, err := ParsePath(name)
+	if err != nil {
+		return fmt.Errorf("cannot set default for %s: %w", name, err)
+	}
+	site := callerSite(caller)
 	if definedValue, ok := defined.Get(name); ok {
-		return fmt.Errorf("cannot set default for %s=(%T)%+v (already defined)", name, definedValue, definedValue)
+		return fmt.Errorf("cannot set default for %s: already defined as (%T)%+v", name, definedValue, definedValue)
 	}
 	if err := defaults.Set(name, defaultValue); err != nil {
-		return fmt.Errorf("failed to set default in defaults: %v", err)
+		//a conflict is a different default set elsewhere
+		existing, hasExisting := defaults.Get(name)
+		existingSite := defaultSiteOf(name)
+		if hasExisting && existingSite != "" && existingSite != site && !sameDefault(existing, defaultValue) {
+			return addConflict(&DefaultConflict{Name: name, Site: site, Existing: existingSite, Err: err})
+		}
+		return fmt.Errorf("cannot set default for %s: %v", name, err)
+	}
+	setOwner(p, caller)
+	setDefaultSite(p, site)
+	return nil
+}
+
+//SetDefaultStruct sets the default value for name from a struct
+//the struct fields are stored in defaults with their json names,
+//using default tags for fields with zero values, e.g.:
+//	config.SetDefaultStruct("server.http", httpServerConfig{Port: 8000})
+//the struct type is also registered for name, so GetStruct(name, nil) returns this type
+func SetDefaultStruct(name string, tmpl interface{}) error {
+	t := reflect.TypeOf(tmpl)
+	if t == nil || (t.Kind() != reflect.Struct && !(t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct)) {
+		return fmt.Errorf("cannot set default struct for %s from (%T) (not a struct)", name, tmpl)
+	}
+	defaultValue, err := structValue(reflect.ValueOf(tmpl))
+	if err != nil {
+		return fmt.Errorf("cannot set default struct for %s: %v", name, err)
+	}
+	if err := setDefault(name, defaultValue, logger.GetCaller(2)); err != nil {
+		return err
 	}
+	registerType(name, t)
 	return nil
 }
+
+//sameDefault compares an existing default with a new default value,
+//which is stored in the same way first, e.g. objects as values
+func sameDefault(existing, value interface{}) bool {
+	stored := NewValues("default", nil)
+	if err := stored.Set("value", value); err != nil {
+		return false
+	}
+	v, _ := stored.Get("value")
+	return reflect.DeepEqual(existing, v)
+}
@@ -84,39 +84,75 @@ type batchServerConfig struct {
 
 func TestContentiousStructs(t *testing.T) {
 	//define defaults for both server configs
-	config.SetDefault("server.http", httpServerConfig{
+	config.SetDefault("contentious.http", httpServerConfig{
 		LimitTPS: 10,
 	})
-	config.SetDefault("server.batch", batchServerConfig{})
+	config.SetDefault("contentious.batch", batchServerConfig{})
 
 	//both servers have defaults,
 	//so GetNamed will fail because default has two items and one must be selected somehow!!!
 	//in this test we have no config source, so cannot test selection
-	if name, _, ok := config.GetNamed("server"); ok {
-		t.Fatalf("got server(%s) but none was selected", name)
+	if name, _, ok := config.GetNamed("contentious"); ok {
+		t.Fatalf("got contentious(%s) but none was selected", name)
 	}
 }
 
 func TestStructSelection(t *testing.T) {
 	//define defaults for both server configs
-	config.SetDefault("server.http", httpServerConfig{
+	config.SetDefault("selected.http", httpServerConfig{
 		LimitTPS: 10,
 	})
-	config.SetDefault("server.batch", batchServerConfig{})
+	config.SetDefault("selected.batch", batchServerConfig{})
 
 	//add static config to make a selection
 	static.Add(map[string]interface{}{
-		"server": map[string]interface{}{
+		"selected": map[string]interface{}{
 			"http": nil,
 		},
 	})
 
 	//both servers have defaults, but http is configured with nil value
-	//so GetNamed() should get nil, and call Validate()
+	//so it is selected, the defaults of batch do not count
+	if name, _, ok := config.GetNamed("selected"); !ok || name != "http" {
+		t.Fatalf("got selected(%s),%v but http was selected", name, ok)
+	}
+}
 
-	//so GetNamed will fail because default has two items and one must be selected somehow!!!
-	//in this test we have no config source, so cannot test selection
-	if name, _, ok := config.GetNamed("server"); ok {
-		t.Fatalf("got server(%s) but none was selected", name)
+func TestDefaultStruct(t *testing.T) {
+	if err := config.SetDefaultStruct("structdefault.http", httpServerConfig{Address: "myhost"}); err != nil {
+		t.Fatalf("failed to set default struct: %v", err)
+	}
+	if err := config.SetDefaultStruct("structdefault.port", 8000); err == nil {
+		t.Fatalf("able to set default struct from int")
+	}
+
+	//registered type is used when no template is given
+	c, err := config.GetStruct("structdefault.http", nil)
+	if err != nil {
+		t.Fatalf("failed to get struct: %v", err)
+	}
+	if http, ok := c.(httpServerConfig); !ok || http.Address != "myhost" || http.Port != 8000 {
+		t.Fatalf("wrong value (%T)%+v", c, c)
+	}
+
+	//zero fields use default tags
+	if err := config.SetDefaultStruct("structdefault.tagged", taggedServerConfig{Address: "myhost"}); err != nil {
+		t.Fatalf("failed to set default struct: %v", err)
+	}
+	if c, err := config.GetStruct("structdefault.tagged", nil); err != nil {
+		t.Fatalf("failed to get struct: %v", err)
+	} else if tagged := c.(taggedServerConfig); tagged.Address != "myhost" || tagged.Port != 8000 || tagged.Timeout.String() != "30s" || !tagged.TLS.Enabled {
+		t.Fatalf("wrong value (%T)%+v", c, c)
+	}
+
+	//fields are stored with json names
+	if timeout, ok := config.GetString("structdefault.tagged.timeout"); !ok || timeout != "30s" {
+		t.Fatalf("timeout->%v,%v", timeout, ok)
+	}
+	if address, ok := config.GetString("structdefault.http.address"); !ok || address != "myhost" {
+		t.Fatalf("address->%v,%v", address, ok)
+	}
+	if limit, ok := config.GetInt("structdefault.http.limit_tps"); !ok || limit != 0 {
+		t.Fatalf("limit_tps->%v,%v", limit, ok)
 	}
 }
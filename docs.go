@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"strings"
+)
+
+//DocEntry documents one config value, see Documented()
+type DocEntry struct {
+	Key         string //full name, e.g. "server.http.port"
+	Type        string //Go type of the struct field, or of the default value
+	Default     string //as JSON, empty when there is no default
+	Allowed     string //from validate tags, e.g. "1..65535" or "one of http, https"
+	Env         string //variable to set the value in env, from the env tag or the top-level name
+	Required    bool
+	Description string //from the doc tag
+	Package     string //Go package that set the default or registered the struct
+}
+
+//Documented returns documentation for each known value, sorted by key:
+//all defaults, and the fields of registered structs and named templates
+func Documented() []DocEntry {
+	entries := []DocEntry{}
+	documentNode(&entries, nil, sampleTree())
+	return entries
+}
+
+func documentNode(entries *[]DocEntry, p Path, node *sampleNode) {
+	for _, name := range sortedKeys(node.fields) {
+		child := node.fields[name]
+		childPath := p.Add(name)
+		if len(child.fields) > 0 {
+			documentNode(entries, childPath, child)
+			continue
+		}
+		key := childPath.String()
+		entry := DocEntry{
+			Key:         key,
+			Env:         envNameOf(childPath, child),
+			Required:    child.required,
+			Description: child.doc,
+			Package:     ownerOf(key),
+		}
+		switch {
+		case child.t != nil:
+			entry.Type = child.t.String()
+		case child.hasValue && child.value != nil:
+			entry.Type = reflect.TypeOf(child.value).String()
+		}
+		if child.hasValue {
+			if data, err := json.Marshal(child.value); err == nil {
+				entry.Default = string(data)
+			}
+		}
+		allowed := []string{}
+		min, max := "", ""
+		for _, r := range child.rules {
+			switch r.name {
+			case "min":
+				min = r.param
+			case "max":
+				max = r.param
+			case "oneof":
+				allowed = append(allowed, "one of "+strings.ReplaceAll(r.param, "|", ", "))
+			case "hostname", "url":
+				allowed = append(allowed, r.name)
+			case "regexp":
+				allowed = append(allowed, "matches "+r.param)
+			case "required_if":
+				allowed = append(allowed, "required if "+strings.Replace(r.param, " ", " is ", 1))
+			}
+		}
+		switch {
+		case min != "" && max != "":
+			allowed = append([]string{min + ".." + max}, allowed...)
+		case min != "":
+			allowed = append([]string{">= " + min}, allowed...)
+		case max != "":
+			allowed = append([]string{"<= " + max}, allowed...)
+		}
+		entry.Allowed = strings.Join(allowed, ", ")
+		*entries = append(*entries, entry)
+	}
+} //documentNode()
+
+//WriteDocs writes reference documentation of all known values, see Documented()
+//format is "markdown" or "html"
+func WriteDocs(w io.Writer, format string) error {
+	entries := Documented()
+	buf := bytes.NewBuffer(nil)
+	switch format {
+	case "markdown":
+		buf.WriteString("| Key | Type | Default | Allowed | Env | Description | Package |\n")
+		buf.WriteString("|-----|------|---------|---------|-----|-------------|---------|\n")
+		for _, e := range entries {
+			description := e.Description
+			if e.Required {
+				description = strings.TrimSpace("**Required.** " + description)
+			}
+			fmt.Fprintf(buf, "| %s | %s | %s | %s | %s | %s | %s |\n",
+				markdownCode(e.Key), markdownCell(e.Type), markdownCode(e.Default), markdownCell(e.Allowed),
+				markdownCode(e.Env), markdownCell(description), markdownCell(e.Package))
+		}
+	case "html":
+		if err := docsTemplate.Execute(buf, entries); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown docs format \"%s\" expecting markdown|html", format)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+} //WriteDocs()
+
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(s) + "`"
+}
+
+var docsTemplate = template.Must(template.New("docs").Parse(`<table class="config">
+<thead><tr><th>Key</th><th>Type</th><th>Default</th><th>Allowed</th><th>Env</th><th>Description</th><th>Package</th></tr></thead>
+<tbody>
+{{- range .}}
+<tr><td><code>{{.Key}}</code></td><td>{{.Type}}</td><td>{{if .Default}}<code>{{.Default}}</code>{{end}}</td><td>{{.Allowed}}</td><td><code>{{.Env}}</code></td><td>{{if .Required}}<strong>Required.</strong> {{end}}{{.Description}}</td><td>{{.Package}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+`))
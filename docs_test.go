@@ -0,0 +1,57 @@
+package config_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+)
+
+type docsHTTPConfig struct {
+	Scheme string `json:"scheme" default:"http" validate:"oneof=http|https" doc:"URL scheme"`
+	Port   int    `json:"port" default:"8000" env:"HTTP_PORT" validate:"min=1,max=65535" doc:"TCP port to listen on"`
+	Name   string `json:"name" required:"true" doc:"Name | label"`
+}
+
+func TestDocumented(t *testing.T) {
+	config.Register("docs.http", docsHTTPConfig{})
+	config.SetDefault("docs.level", "info")
+
+	entries := []config.DocEntry{}
+	for _, e := range config.Documented() {
+		if strings.HasPrefix(e.Key, "docs.") {
+			entries = append(entries, e)
+		}
+	}
+	const pkg = "github.com/stewelarend/config_test"
+	expected := []config.DocEntry{
+		{Key: "docs.http.name", Type: "string", Required: true, Description: "Name | label", Package: pkg},
+		{Key: "docs.http.port", Type: "int", Default: "8000", Allowed: "1..65535", Env: "HTTP_PORT", Description: "TCP port to listen on", Package: pkg},
+		{Key: "docs.http.scheme", Type: "string", Default: `"http"`, Allowed: "one of http, https", Description: "URL scheme", Package: pkg},
+		{Key: "docs.level", Type: "string", Default: `"info"`, Package: pkg},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("entries=%+v", entries)
+	}
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Errorf("entry[%d]=%+v, expected %+v", i, entries[i], expected[i])
+		}
+	}
+
+	markdown := bytes.NewBuffer(nil)
+	if err := config.WriteDocs(markdown, "markdown"); err != nil {
+		t.Fatal(err)
+	}
+	if line := "| `docs.http.name` | string |  |  |  | **Required.** Name \\| label | " + pkg + " |\n"; !strings.Contains(markdown.String(), line) {
+		t.Errorf("markdown:\n%s\nexpected to contain:\n%s", markdown, line)
+	}
+	html := bytes.NewBuffer(nil)
+	if err := config.WriteDocs(html, "html"); err != nil {
+		t.Fatal(err)
+	}
+	if row := `<tr><td><code>docs.http.scheme</code></td><td>string</td><td><code>&#34;http&#34;</code></td><td>one of http, https</td>`; !strings.Contains(html.String(), row) {
+		t.Errorf("html:\n%s\nexpected to contain:\n%s", html, row)
+	}
+}
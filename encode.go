@@ -0,0 +1,393 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//Formats that Encode() and Export() can write
+var Formats = []string{"json", "yaml", "toml", "env"}
+
+//Encode writes a config value tree in one of the Formats
+//see Export() to write the config of this process
+func Encode(w io.Writer, tree map[string]interface{}, format string) error {
+	return encode(w, tree, format, nil)
+}
+
+//encode writes the tree with optional comments for leaf values by name
+//JSON has no comments, so they are not written
+func encode(w io.Writer, tree map[string]interface{}, format string, comments map[string]string) error {
+	buf := bytes.NewBuffer(nil)
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return err
+		}
+		buf.Write(append(data, '\n'))
+	case "yaml":
+		if err := encodeYAML(buf, "", nil, tree, comments); err != nil {
+			return err
+		}
+	case "toml":
+		if err := encodeTOML(buf, nil, tree, comments); err != nil {
+			return err
+		}
+	case "env":
+		if err := encodeEnv(buf, tree, comments); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format \"%s\" expecting %s", format, strings.Join(Formats, "|"))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+} //encode()
+
+func sortedNames(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//comment formats the comment for name, if any
+func comment(comments map[string]string, p Path) string {
+	if c, ok := comments[p.String()]; ok && c != "" {
+		return " # " + c
+	}
+	return ""
+}
+
+//encodeYAML writes an object at the indent
+//it is written by hand rather than with yaml.Marshal() to support comments
+func encodeYAML(buf *bytes.Buffer, indent string, p Path, obj map[string]interface{}, comments map[string]string) error {
+	for _, k := range sortedNames(obj) {
+		itemPath := p.Add(k)
+		key, err := yamlScalar(k)
+		if err != nil {
+			return err
+		}
+		switch v := obj[k].(type) {
+		case map[string]interface{}:
+			if len(v) == 0 {
+				fmt.Fprintf(buf, "%s%s: {}%s\n", indent, key, comment(comments, itemPath))
+				continue
+			}
+			fmt.Fprintf(buf, "%s%s:%s\n", indent, key, comment(comments, itemPath))
+			if err := encodeYAML(buf, indent+"  ", itemPath, v, comments); err != nil {
+				return err
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				fmt.Fprintf(buf, "%s%s: []%s\n", indent, key, comment(comments, itemPath))
+				continue
+			}
+			fmt.Fprintf(buf, "%s%s:%s\n", indent, key, comment(comments, itemPath))
+			for _, item := range v {
+				if itemObj, ok := item.(map[string]interface{}); ok && len(itemObj) > 0 {
+					//first field on the same line as "- "
+					itemBuf := bytes.NewBuffer(nil)
+					if err := encodeYAML(itemBuf, indent+"  ", nil, itemObj, nil); err != nil {
+						return err
+					}
+					buf.WriteString(indent + "- " + strings.TrimPrefix(itemBuf.String(), indent+"  "))
+					continue
+				}
+				s, err := yamlScalar(item)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(buf, "%s- %s\n", indent, s)
+			}
+		default:
+			s, err := yamlScalar(v)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "%s%s: %s%s\n", indent, key, s, comment(comments, itemPath))
+		}
+	}
+	return nil
+} //encodeYAML()
+
+//yamlScalar writes a value on one line
+//multi-line strings, lists and objects use JSON, which is also valid YAML
+func yamlScalar(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		if !strings.ContainsAny(value, "\r\n") {
+			break
+		}
+		data, err := json.Marshal(value)
+		return string(data), err
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(value)
+		return string(data), err
+	}
+	data, err := yaml.Marshal(v)
+	return strings.TrimSuffix(string(data), "\n"), err
+}
+
+//encodeTOML writes the table at path: values first, then sub-tables
+//lists of objects are written as arrays of tables, nil values are skipped
+func encodeTOML(buf *bytes.Buffer, p Path, table map[string]interface{}, comments map[string]string) error {
+	tables := []string{}
+	tableLists := []string{}
+	for _, k := range sortedNames(table) {
+		switch v := table[k].(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			tables = append(tables, k)
+			continue
+		case []interface{}:
+			if len(v) > 0 && isObjectList(v) {
+				tableLists = append(tableLists, k)
+				continue
+			}
+		}
+		s, err := tomlValue(table[k])
+		if err != nil {
+			return fmt.Errorf("%s: %v", p.Add(k), err)
+		}
+		fmt.Fprintf(buf, "%s = %s%s\n", tomlKey(k), s, comment(comments, p.Add(k)))
+	}
+
+	for _, k := range tables {
+		subPath := p.Add(k)
+		if hasTOMLValues(table[k].(map[string]interface{})) {
+			tomlHeader(buf, "["+tomlPath(subPath)+"]")
+		}
+		if err := encodeTOML(buf, subPath, table[k].(map[string]interface{}), comments); err != nil {
+			return err
+		}
+	}
+	for _, k := range tableLists {
+		subPath := p.Add(k)
+		for _, item := range table[k].([]interface{}) {
+			tomlHeader(buf, "[["+tomlPath(subPath)+"]]")
+			if err := encodeTOML(buf, subPath, item.(map[string]interface{}), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+} //encodeTOML()
+
+//tomlHeader writes a table header, separated from previous lines
+func tomlHeader(buf *bytes.Buffer, header string) {
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString(header + "\n")
+}
+
+//hasTOMLValues is false for tables with only sub-tables, which do not need a header
+func hasTOMLValues(table map[string]interface{}) bool {
+	for _, v := range table {
+		switch v := v.(type) {
+		case nil, map[string]interface{}:
+			continue
+		case []interface{}:
+			if len(v) > 0 && isObjectList(v) {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isObjectList(list []interface{}) bool {
+	for _, item := range list {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var bareKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(k string) string {
+	if bareKeyRegex.MatchString(k) {
+		return k
+	}
+	return tomlString(k)
+}
+
+func tomlPath(p Path) string {
+	keys := make([]string, len(p))
+	for i, k := range p {
+		keys[i] = tomlKey(k)
+	}
+	return strings.Join(keys, ".")
+}
+
+//tomlValue formats an inline value
+func tomlValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return tomlString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case map[string]interface{}:
+		items := make([]string, 0, len(v))
+		for _, k := range sortedNames(v) {
+			if v[k] == nil {
+				continue
+			}
+			s, err := tomlValue(v[k])
+			if err != nil {
+				return "", err
+			}
+			items = append(items, tomlKey(k)+" = "+s)
+		}
+		return "{" + strings.Join(items, ", ") + "}", nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		switch {
+		case math.IsNaN(f):
+			return "nan", nil
+		case math.IsInf(f, 1):
+			return "inf", nil
+		case math.IsInf(f, -1):
+			return "-inf", nil
+		case f == math.Trunc(f) && math.Abs(f) < 1e15:
+			//JSON numbers are float64, write integral values as integers
+			return strconv.FormatInt(int64(f), 10), nil
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	}
+	return "", fmt.Errorf("cannot write (%T)%v as TOML", value, value)
+} //tomlValue()
+
+//tomlString is a TOML basic string
+func tomlString(s string) string {
+	buf := bytes.NewBufferString(`"`)
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteString(`"`)
+	return buf.String()
+} //tomlString()
+
+//encodeEnv writes NAME=value lines for the leaf values that are read from env,
+//named with the env tag of their struct field or their top-level name, as in WriteSample()
+//other values cannot be set in env and are written as comments with their names
+//lists are comma separated and objects in lists are written as JSON
+func encodeEnv(buf *bytes.Buffer, tree map[string]interface{}, comments map[string]string) error {
+	names := envNames()
+	leaves := NewValues("env", tree)
+	for _, key := range leaves.Keys() {
+		value, _ := leaves.Get(key)
+		s, err := envValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		if c, ok := comments[key]; ok && c != "" {
+			fmt.Fprintf(buf, "# %s\n", c)
+		}
+		env, ok := names[key]
+		if !ok && envVariableRegex.MatchString(key) {
+			env, ok = key, true //top-level value
+		}
+		if ok {
+			fmt.Fprintf(buf, "%s=%s\n", env, s)
+			continue
+		}
+		fmt.Fprintf(buf, "# %s=%s (not read from env)\n", key, s)
+	}
+	return nil
+}
+
+var envSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_./:,=@+-]*$`)
+
+func envValue(value interface{}) (string, error) {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		s = ""
+	case string:
+		s = v
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			if _, ok := item.(map[string]interface{}); ok {
+				data, err := json.Marshal(v)
+				if err != nil {
+					return "", err
+				}
+				return envQuote(string(data)), nil
+			}
+			items[i] = fmt.Sprintf("%v", item)
+		}
+		s = strings.Join(items, ",")
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+	if envSafeRegex.MatchString(s) {
+		return s, nil
+	}
+	return envQuote(s), nil
+} //envValue()
+
+//envQuote quotes a value for env files, as understood by docker and dotenv
+func envQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, `$`, `\$`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,107 @@
+package config_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+func TestEncode(t *testing.T) {
+	tree := map[string]interface{}{
+		"server": map[string]interface{}{
+			"http": map[string]interface{}{
+				"port":  8000,
+				"hosts": []interface{}{"a", "b"},
+			},
+			"name": "my server",
+		},
+		"routes":  []interface{}{map[string]interface{}{"path": "/api", "methods": []interface{}{"GET"}}},
+		"ratio":   0.5,
+		"api.key": "x\ny",
+	}
+	tests := map[string]string{
+		"json": "{\n  \"api.key\": \"x\\ny\",\n  \"ratio\": 0.5,\n  \"routes\": [\n    {\n      \"methods\": [\n        \"GET\"\n      ],\n      \"path\": \"/api\"\n    }\n  ],\n  \"server\": {\n    \"http\": {\n      \"hosts\": [\n        \"a\",\n        \"b\"\n      ],\n      \"port\": 8000\n    },\n    \"name\": \"my server\"\n  }\n}\n",
+		"yaml": "api.key: \"x\\ny\"\nratio: 0.5\nroutes:\n- methods:\n  - GET\n  path: /api\nserver:\n  http:\n    hosts:\n    - a\n    - b\n    port: 8000\n  name: my server\n",
+		"toml": "\"api.key\" = \"x\\ny\"\nratio = 0.5\n\n[server]\nname = \"my server\"\n\n[server.http]\nhosts = [\"a\", \"b\"]\nport = 8000\n\n[[routes]]\nmethods = [\"GET\"]\npath = \"/api\"\n",
+		"env":  "# \"api.key\"=\"x\\ny\" (not read from env)\nratio=0.5\nroutes=\"[{\\\"methods\\\":[\\\"GET\\\"],\\\"path\\\":\\\"/api\\\"}]\"\n# server.http.hosts=a,b (not read from env)\n# server.http.port=8000 (not read from env)\n# server.name=\"my server\" (not read from env)\n",
+	}
+	for format, expected := range tests {
+		buf := bytes.NewBuffer(nil)
+		if err := config.Encode(buf, tree, format); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if buf.String() != expected {
+			t.Errorf("%s:\n%s\nexpected:\n%s", format, buf.String(), expected)
+		}
+	}
+	if err := config.Encode(bytes.NewBuffer(nil), tree, "xml"); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
+
+type envEncodedConfig struct {
+	Port int    `json:"port" env:"ENVENCODED_PORT"`
+	Name string `json:"name"`
+}
+
+func TestEncodeEnv(t *testing.T) {
+	//names are the env tags, as read by GetStruct() and written by WriteSample()
+	if err := config.Register("envencoded.http", envEncodedConfig{}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	buf := bytes.NewBuffer(nil)
+	tree := map[string]interface{}{"envencoded": map[string]interface{}{"http": map[string]interface{}{"port": 8000, "name": "web"}}}
+	if err := config.Encode(buf, tree, "env"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "# envencoded.http.name=web (not read from env)\nENVENCODED_PORT=8000\n"; buf.String() != expected {
+		t.Fatalf("env:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestExport(t *testing.T) {
+	config.SetDefault("exported", map[string]interface{}{"port": 8000, "address": "localhost", "db": map[string]interface{}{"password": "default"}})
+	static.Add(map[string]interface{}{"exported": map[string]interface{}{"port": 9000, "db": map[string]interface{}{"password": "secret"}}})
+
+	//other tests define values too, only look at exported
+	export := func(opts config.ExportOptions) interface{} {
+		buf := bytes.NewBuffer(nil)
+		if err := config.Export(buf, "json", opts); err != nil {
+			t.Fatal(err)
+		}
+		var tree map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &tree); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(tree["exported"])
+		return string(data)
+	}
+	if s := export(config.ExportOptions{}); s != "null" {
+		t.Fatalf("not used yet, but exported %s", s)
+	}
+	if s := export(config.ExportOptions{Resolved: true, Redact: config.SecretWords}); s != `{"address":"localhost","db":{"password":"***"},"port":9000}` {
+		t.Fatalf("resolved %s", s)
+	}
+	if s := export(config.ExportOptions{Resolved: true, NonDefault: true}); s != `{"db":{"password":"secret"},"port":9000}` {
+		t.Fatalf("non-default %s", s)
+	}
+	if port, ok := config.GetInt("exported.port"); !ok || port != 9000 {
+		t.Fatalf("port=%v,%v", port, ok)
+	}
+	if s := export(config.ExportOptions{}); s != `{"port":9000}` {
+		t.Fatalf("defined %s", s)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := config.Export(buf, "yaml", config.ExportOptions{Resolved: true, Provenance: true}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "exported:\n  address: localhost # from defaults\n  db:\n    password: secret # from static\n  port: 9000 # from static\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Fatalf("yaml:\n%s\nexpected to contain:\n%s", buf.String(), expected)
+	}
+}
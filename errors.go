@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//errors returned by Lookup() and the Get*E() functions
+//test with errors.Is() and errors.As(), e.g.:
+//
+//	if errors.Is(err, config.ErrNotDefined) {...}
+//	var typeErr *config.TypeError
+//	if errors.As(err, &typeErr) {...}
+var (
+	ErrNotDefined   = errors.New("not defined")
+	ErrInvalidName  = errors.New("invalid name")
+	ErrFrozen       = errors.New("config is frozen")        //see Freeze()
+	ErrNotSupported = errors.New("not supported by source") //see IContextSource
+)
+
+//TypeError is returned when a value cannot convert to the requested type
+type TypeError struct {
+	Key    string      //name of the value, e.g. "server.http.port"
+	Want   string      //type or description of what was expected, e.g. "int"
+	Got    interface{} //the value that was found
+	Source string      //name of the source that defined the value, if known
+	Err    error       //optional cause, e.g. from a parser
+}
+
+func (e *TypeError) Error() string {
+	s := fmt.Sprintf("expected %s, got %s", e.Want, describe(e.Got))
+	if e.Key != "" {
+		s = e.Key + ": " + s
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	if e.Source != "" {
+		s += " (from " + e.Source + ")"
+	}
+	return s
+}
+
+func (e *TypeError) Unwrap() error {
+	return e.Err
+}
+
+//UnknownKeyError is returned in strict mode for a configured key
+//that is not used by the struct and not defined in the defaults
+type UnknownKeyError struct {
+	Key    string //full name, e.g. "server.http.prot"
+	Source string //name of the source that has the key, e.g. the filename
+}
+
+func (e *UnknownKeyError) Error() string {
+	msg := "unknown key"
+	if e.Key != "" {
+		msg += " " + e.Key
+	}
+	if e.Source != "" {
+		msg += fmt.Sprintf(" (in %s)", e.Source)
+	}
+	return msg
+}
+
+//ValidationError is a validation failure of one value
+type ValidationError struct {
+	Path string //full name of the value, e.g. "server.http.port"
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+//ValidationErrors is returned when validation fails, with all the failures
+//not only the first, so that all problems in the config can be fixed at once
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//Is and As match any of the errors, also before Go 1.20,
+//where errors.Is() and errors.As() do not use Unwrap() []error
+func (e ValidationErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ValidationErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+//withKey prefixes the key of a TypeError with the parent name
+//other errors are returned as is
+func withKey(err error, parent string) error {
+	var typeErr *TypeError
+	if errors.As(err, &typeErr) {
+		typeErr.Key = joinKey(parent, typeErr.Key)
+	}
+	return err
+}
+
+//joinKey appends a child key to a parent, e.g. "server"+"port" or "hosts"+"[0]"
+func joinKey(parent, child string) string {
+	switch {
+	case parent == "":
+		return child
+	case child == "":
+		return parent
+	case child[0] == '[':
+		return parent + child
+	}
+	return parent + "." + child
+}
+
+//SourceError is returned when a source failed to provide a value,
+//e.g. when it did not respond within its timeout (see WithSourceTimeout())
+type SourceError struct {
+	Source string //name of the source
+	Key    string //name of the value, e.g. "server.http.port"
+	Err    error  //cause, e.g. context.DeadlineExceeded
+}
+
+func (e *SourceError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("source %s failed: %v", e.Source, e.Err)
+	}
+	return fmt.Sprintf("%s: source %s failed: %v", e.Key, e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,39 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+func TestLookupErrors(t *testing.T) {
+	static.Add(map[string]interface{}{
+		"errs": map[string]interface{}{
+			"port":  "abc",
+			"ports": []interface{}{80, "x"},
+		},
+	})
+
+	if v, err := config.Lookup("errs.unknown"); !errors.Is(err, config.ErrNotDefined) {
+		t.Fatalf("Lookup(errs.unknown)->%v,%v", v, err)
+	}
+	if v, err := config.Lookup("errs..port"); !errors.Is(err, config.ErrInvalidName) {
+		t.Fatalf("Lookup(errs..port)->%v,%v", v, err)
+	}
+	if v, err := config.GetIntE("errs.unknown"); !errors.Is(err, config.ErrNotDefined) {
+		t.Fatalf("GetIntE(errs.unknown)->%v,%v", v, err)
+	}
+
+	var typeErr *config.TypeError
+	if v, err := config.GetIntE("errs.port"); !errors.As(err, &typeErr) || typeErr.Key != "errs.port" || typeErr.Want != "int" || typeErr.Got != "abc" || typeErr.Source != "static" {
+		t.Fatalf("GetIntE(errs.port)->%v,%v", v, err)
+	}
+	if err := typeErr.Error(); err != `errs.port: expected int, got "abc" (from static)` {
+		t.Fatalf("wrong message: %s", err)
+	}
+	if v, err := config.Bind[[]int]("errs.ports"); !errors.As(err, &typeErr) || typeErr.Key != "errs.ports[1]" {
+		t.Fatalf("Bind(errs.ports)->%v,%v", v, err)
+	}
+}
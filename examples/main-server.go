@@ -12,30 +12,29 @@ func main() {
 		panic(err)
 	}
 
-	//retrieve a named struct with optional validation:
+	//construct the configured server with optional validation:
 	//e.g. we want the server and it could be http or zmq:
 	//change the config file "http" to "zmq" to get the other option
 	//then run again...
-	options := map[string]interface{}{
-		"http": httpServerConfig{},
-		"zmq":  zmqServerConfig{},
+	//each implementation registered itself in init(), so importing
+	//another implementation is all it takes to support it
+	server, err := config.Construct[IServer]("server")
+	if err != nil {
+		panic(err)
 	}
-	if named, cfg, err := config.GetNamedStruct("server", options); err != nil {
+	fmt.Printf("configured server: (%T)%+v\n", server, server)
+	if err := server.Serve(); err != nil {
 		panic(err)
-	} else {
-		fmt.Printf("named server(%s) config struct: (%T)%+v\n", named, cfg, cfg)
+	}
+}
 
-		//now you can create the configured type of server
-		//as long as both config structures implements the constructor interface:
-		server, err := cfg.(IServerConstructor).Create()
-		if err != nil {
-			panic(err)
-		}
-		if err := server.Serve(); err != nil {
-			panic(err)
-		}
+type IServer interface {
+	Serve() error
+}
 
-	}
+func init() {
+	config.RegisterImpl[IServer]("server", "http", httpServerConfig{}, newHTTPServer)
+	config.RegisterImpl[IServer]("server", "zmq", zmqServerConfig{}, newZMQServer)
 }
 
 type httpServerConfig struct {
@@ -43,7 +42,7 @@ type httpServerConfig struct {
 	Port    int    `json:"port"`
 }
 
-func (c httpServerConfig) Create() (IServer, error) {
+func newHTTPServer(c httpServerConfig) (IServer, error) {
 	return httpServer{config: c}, nil
 }
 
@@ -52,18 +51,10 @@ type zmqServerConfig struct {
 	Port    int    `json:"port"`
 }
 
-func (c zmqServerConfig) Create() (IServer, error) {
+func newZMQServer(c zmqServerConfig) (IServer, error) {
 	return zmqServer{config: c}, nil
 }
 
-type IServerConstructor interface {
-	Create() (IServer, error)
-}
-
-type IServer interface {
-	Serve() error
-}
-
 type httpServer struct {
 	config httpServerConfig
 }
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"io"
+	"strings"
+)
+
+//ExportOptions control what Export() writes
+type ExportOptions struct {
+	//Resolved exports the full tree (see Resolved()) rather than the values used so far (see Defined())
+	Resolved bool
+	//Redact replaces values with "***" when any segment of the name contains
+	//any of these words (case-insensitive), e.g. config.SecretWords
+	Redact []string
+	//Provenance writes the source of each value as a comment (not for JSON)
+	Provenance bool
+	//NonDefault exports only values that differ from the defaults
+	NonDefault bool
+}
+
+//SecretWords are names of values that usually hold secrets, for ExportOptions.Redact
+var SecretWords = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "private_key", "credential"}
+
+//Export writes the config of this process in one of the Formats,
+//e.g. for a support bundle or to reproduce the config elsewhere:
+//	config.Export(os.Stdout, "yaml", config.ExportOptions{Resolved: true, Redact: config.SecretWords, Provenance: true})
+//in "env" format, values are named with their env tags, see WriteSample()
+func Export(w io.Writer, format string, opts ExportOptions) error {
+	var tree *values
+	var leafSources map[string]string
+	if opts.Resolved {
+		tree, leafSources = resolve()
+	} else {
+		tree, leafSources = defined, map[string]string{}
+	}
+
+	exported := NewValues("export", nil)
+	comments := map[string]string{}
+	for _, key := range tree.Keys() {
+		value, _ := tree.Get(key)
+		if opts.NonDefault {
+			if defaultValue, ok := defaults.Get(key); ok && schemaEqual(defaultValue, value) {
+				continue
+			}
+		}
+		if isSecret(key, opts.Redact) {
+			value = "***"
+		}
+		if err := exported.Set(key, value); err != nil {
+			return err
+		}
+		if opts.Provenance {
+			source, ok := leafSources[key]
+			if !ok {
+				source = sourceOf(key)
+			}
+			comments[key] = "from " + source
+		}
+	}
+	return encode(w, exported.Value(), format, comments)
+} //Export()
+
+func isSecret(key string, words []string) bool {
+	p, err := ParsePath(key)
+	if err != nil {
+		return false
+	}
+	for _, segment := range p {
+		for _, word := range words {
+			if strings.Contains(strings.ToLower(segment), strings.ToLower(word)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package config
+
+//Unfreeze allows tests to continue after Freeze()
+var Unfreeze = unfreeze
+
+//OwnerOf is the package that set the default or registered a name
+var OwnerOf = ownerOf
+
+//DefaultSiteOf is where the default for a name was set
+var DefaultSiteOf = defaultSiteOf
+
+//Unregister removes a name registered by a test
+var Unregister = unregister
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/stewelarend/logger"
+)
+
+//implementation is a registered implementation of a named value
+type implementation struct {
+	template    interface{}
+	constructor func(cfg interface{}) (interface{}, error)
+}
+
+var (
+	implementationsMutex sync.Mutex
+	implementations      = map[string]map[string]implementation{}
+)
+
+//RegisterImpl registers an implementation of a named value, usually in init()
+//of the package that implements it, e.g.:
+//	func init() {
+//		config.RegisterImpl[IServer]("server", "http", httpServerConfig{Port: 8000}, newHTTPServer)
+//	}
+//	func newHTTPServer(c httpServerConfig) (IServer, error) {...}
+//the template also registers the item, see RegisterNamed()
+//then Construct[IServer]("server") creates the configured implementation
+//it panics when the constructor is nil, the template is not valid or
+//the item is already registered
+func RegisterImpl[T any, C any](name string, named string, template C, constructor func(cfg C) (T, error)) {
+	if constructor == nil {
+		panic(fmt.Errorf("cannot register %s.%s without constructor", name, quoteSegment(named)))
+	}
+	implementationsMutex.Lock()
+	defer implementationsMutex.Unlock()
+	if _, ok := implementations[name][named]; ok {
+		panic(fmt.Errorf("cannot register %s.%s: already registered", name, quoteSegment(named)))
+	}
+	if err := registerNamed(name, map[string]interface{}{named: template}, logger.GetCaller(2)); err != nil {
+		panic(err)
+	}
+	if implementations[name] == nil {
+		implementations[name] = map[string]implementation{}
+	}
+	implementations[name][named] = implementation{
+		template: template,
+		constructor: func(cfg interface{}) (interface{}, error) {
+			return constructor(cfg.(C))
+		},
+	}
+} //RegisterImpl()
+
+//Construct creates the implementation of a named value that is configured
+//the item config is decoded into its template and validated like GetNamedStruct()
+//before it is passed to the constructor registered with RegisterImpl()
+func Construct[T any](name string, opts ...Option) (T, error) {
+	var result T
+	implementationsMutex.Lock()
+	impls := map[string]implementation{}
+	templates := map[string]interface{}{}
+	for named, impl := range implementations[name] {
+		impls[named] = impl
+		templates[named] = impl.template
+	}
+	implementationsMutex.Unlock()
+	if len(templates) == 0 {
+		return result, fmt.Errorf("cannot construct %s: no implementations registered", name)
+	}
+
+	named, cfg, err := GetNamedStruct(name, templates, opts...)
+	if err != nil {
+		return result, err
+	}
+	namedName := name + "." + quoteSegment(named)
+	v, err := impls[named].constructor(cfg)
+	if err != nil {
+		return result, fmt.Errorf("cannot construct %s: %w", namedName, err)
+	}
+	if v == nil {
+		return result, nil
+	}
+	result, ok := v.(T)
+	if !ok {
+		return result, fmt.Errorf("cannot construct %s: constructor returned %T, not %v", namedName, v, reflect.TypeOf(&result).Elem())
+	}
+	return result, nil
+} //Construct()
@@ -0,0 +1,86 @@
+package config_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+type iGreeter interface {
+	Greet() string
+}
+
+type englishConfig struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type englishGreeter struct{ name string }
+
+func (g englishGreeter) Greet() string { return "Hello " + g.name }
+
+type frenchConfig struct {
+	Name string `json:"name" default:"monde"`
+}
+
+type frenchGreeter struct{ name string }
+
+func (g frenchGreeter) Greet() string { return "Bonjour " + g.name }
+
+func TestConstruct(t *testing.T) {
+	config.RegisterImpl[iGreeter]("greeter", "english", englishConfig{}, func(c englishConfig) (iGreeter, error) {
+		return englishGreeter{name: c.Name}, nil
+	})
+	config.RegisterImpl[iGreeter]("greeter", "french", frenchConfig{}, func(c frenchConfig) (iGreeter, error) {
+		return frenchGreeter{name: c.Name}, nil
+	})
+	//registering again panics and does not change the registered item
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("registered french twice")
+			}
+		}()
+		config.RegisterImpl[iGreeter]("greeter", "french", englishConfig{}, func(c englishConfig) (iGreeter, error) {
+			return nil, nil
+		})
+	}()
+	if schema, _ := json.Marshal(config.JSONSchema()); !strings.Contains(string(schema), `"monde"`) {
+		t.Fatalf("french template replaced: %s", schema)
+	}
+
+	//nothing configured
+	if _, err := config.Construct[iGreeter]("greeter"); !errors.Is(err, config.ErrNotDefined) {
+		t.Fatalf("expected not defined, got %v", err)
+	}
+
+	//configured item is decoded with defaults and passed to its constructor
+	static.Add(map[string]interface{}{"greeter": map[string]interface{}{"french": map[string]interface{}{}}})
+	g, err := config.Construct[iGreeter]("greeter")
+	if err != nil {
+		t.Fatalf("construct failed: %v", err)
+	}
+	if g.Greet() != "Bonjour monde" {
+		t.Fatalf("greet=%s", g.Greet())
+	}
+
+	//wrong result type
+	if _, err := config.Construct[string]("greeter"); err == nil || !strings.Contains(err.Error(), "not string") {
+		t.Fatalf("expected type error, got %v", err)
+	}
+
+	//item config is validated
+	config.RegisterImpl[iGreeter]("badgreeter", "english", englishConfig{}, func(c englishConfig) (iGreeter, error) {
+		return englishGreeter{name: c.Name}, nil
+	})
+	static.Add(map[string]interface{}{"badgreeter": map[string]interface{}{"english": map[string]interface{}{}}})
+	if _, err := config.Construct[iGreeter]("badgreeter"); err == nil || !strings.Contains(err.Error(), "required") {
+		t.Fatalf("expected required error, got %v", err)
+	}
+	if _, err := config.Construct[iGreeter]("nogreeter"); err == nil {
+		t.Fatalf("constructed without implementations")
+	}
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+//fieldInfo describes a config struct field from its tags:
+//	json:"name"        name of the field in config (default is the Go field name)
+//	default:"8000"     value to use when the field is not configured
+//	env:"HTTP_PORT"    environment variable that overrides the configured value
+//	required:"true"    fail when not configured and no default
+//	doc:"TCP port"     description used in documentation
+//	validate:"min=1"   validation rules, see parseRules()
+type fieldInfo struct {
+	index        []int
+	name         string
+	t            reflect.Type
+	defaultValue string
+	hasDefault   bool
+	env          string
+	required     bool
+	doc          string
+	omitEmpty    bool
+	rules        []rule
+	rulesErr     error
+}
+
+var structFieldsCache sync.Map //reflect.Type -> []fieldInfo
+
+//structFields returns the config fields of a struct type
+//embedded structs without a json name are flattened, as in encoding/json
+func structFields(t reflect.Type) []fieldInfo {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.([]fieldInfo)
+	}
+	fields := []fieldInfo{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		jsonTag := sf.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		jsonName, jsonOptions := jsonTag, ""
+		if i := strings.Index(jsonTag, ","); i >= 0 {
+			jsonName, jsonOptions = jsonTag[:i], jsonTag[i+1:]
+		}
+
+		if sf.Anonymous && jsonName == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, embedded := range structFields(ft) {
+					embedded.index = append([]int{i}, embedded.index...)
+					fields = append(fields, embedded)
+				}
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue //not exported
+		}
+
+		f := fieldInfo{
+			index:     []int{i},
+			name:      jsonName,
+			t:         sf.Type,
+			env:       sf.Tag.Get("env"),
+			required:  sf.Tag.Get("required") == "true",
+			doc:       sf.Tag.Get("doc"),
+			omitEmpty: strings.Contains(","+jsonOptions+",", ",omitempty,"),
+		}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		f.defaultValue, f.hasDefault = sf.Tag.Lookup("default")
+		f.rules, f.rulesErr = parseRules(sf.Tag.Get("validate"))
+		fields = append(fields, f)
+	}
+	structFieldsCache.Store(t, fields)
+	return fields
+} //structFields()
+
+//fieldByIndex is reflect.Value.FieldByIndex() but allocates nil embedded struct pointers
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded %v", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+//structValue converts a Go value into a config value tree
+//structs become objects with their json field names, and zero fields
+//use their default tags, so that the tree holds what GetStruct() would return
+func structValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return structValue(v.Elem())
+	}
+
+	switch v.Type() {
+	case durationType:
+		return v.Interface().(time.Duration).String(), nil
+	}
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		obj := map[string]interface{}{}
+		for _, f := range structFields(v.Type()) {
+			fv, ok := fieldValueIfSet(v, f.index)
+			if !ok || fv.IsZero() {
+				if f.hasDefault {
+					defaultValue, err := convert(f.defaultValue, f.t)
+					if err != nil {
+						return nil, fmt.Errorf("%s: invalid default: %v", f.name, err)
+					}
+					fv = reflect.ValueOf(defaultValue)
+				} else if f.omitEmpty || !ok {
+					continue
+				}
+			}
+			fieldValue, err := structValue(fv)
+			if err != nil {
+				return nil, withKey(err, quoteSegment(f.name))
+			}
+			obj[f.name] = fieldValue
+		}
+		return obj, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := structValue(v.Index(i))
+			if err != nil {
+				return nil, withKey(err, fmt.Sprintf("[%d]", i))
+			}
+			list[i] = item
+		}
+		return list, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		obj := map[string]interface{}{}
+		iter := v.MapRange()
+		for iter.Next() {
+			item, err := structValue(iter.Value())
+			if err != nil {
+				return nil, withKey(err, quoteSegment(fmt.Sprintf("%v", iter.Key())))
+			}
+			obj[fmt.Sprintf("%v", iter.Key())] = item
+		}
+		return obj, nil
+	}
+	return v.Interface(), nil
+} //structValue()
+
+//fieldValueIfSet returns the field at index, or ok=false when it is inside a nil embedded struct pointer
+func fieldValueIfSet(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+//snapshot is the immutable config after Freeze()
+type snapshot struct {
+	values map[string]interface{} //full name -> value, for every object and leaf
+	named  map[string]namedResult //full name -> selected item, for registered named values
+}
+
+//namedResult is the result of LookupNamed()
+type namedResult struct {
+	named string
+	value interface{}
+	err   error
+}
+
+//frozen holds a *snapshot, nil until Freeze()
+var frozen atomic.Value
+
+func init() {
+	frozen.Store((*snapshot)(nil))
+}
+
+func frozenSnapshot() *snapshot {
+	return frozen.Load().(*snapshot)
+}
+
+//Frozen is true after Freeze()
+func Frozen() bool {
+	return frozenSnapshot() != nil
+}
+
+//Freeze resolves all known values and makes the config immutable
+//call it at the end of startup, after adding sources and setting defaults:
+//reads are then served from a snapshot without locks, which is faster
+//when many goroutines read config, and SetDefault() and AddSource() fail with ErrFrozen
+//known values are the defaults, values in sources that list their keys (IKeysSource),
+//registered names and values already used, other names are not defined after the freeze
+//it fails without freezing when keys cannot be listed or values cannot be resolved
+//values inside an object that was already used from an earlier source are
+//shadowed by that object and not defined
+func Freeze() error {
+	if Frozen() {
+		return nil
+	}
+	keys := map[string]bool{}
+	for _, key := range defaults.Keys() {
+		keys[key] = true
+	}
+	errs := []string{}
+	for _, e := range sourceList() {
+		sourceKeys, _, err := e.keys(context.Background())
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		for _, key := range sourceKeys {
+			keys[key] = true
+		}
+	}
+	for _, name := range registeredNames() {
+		keys[name] = true
+	}
+	namedTypes := registeredNamed()
+	for name := range namedTypes {
+		keys[name] = true
+	}
+
+	for _, key := range sortedKeys(keys) {
+		//named values may be answered by INamedSource
+		_, named := namedTypes[key]
+		if _, err := lookup(context.Background(), key, named); err != nil && !errors.Is(err, ErrNotDefined) {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot resolve all values: %s", strings.Join(errs, "; "))
+	}
+	s := &snapshot{values: map[string]interface{}{}, named: map[string]namedResult{}}
+	s.add(nil, defined.Value())
+	//select the named items now, which needs the registry and sources
+	for name := range namedTypes {
+		p, err := ParsePath(name)
+		if err != nil {
+			continue
+		}
+		if v, ok := s.values[p.String()]; ok {
+			named, value, err := namedItem(name, v)
+			s.named[p.String()] = namedResult{named: named, value: value, err: err}
+		}
+	}
+	frozen.Store(s)
+	log.Debugf("frozen with %d values", len(s.values))
+	return nil
+} //Freeze()
+
+//add an object and all its values to the snapshot
+func (s *snapshot) add(p Path, obj map[string]interface{}) {
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		itemPath := p.Add(n)
+		s.values[itemPath.String()] = obj[n]
+		if itemObj, ok := obj[n].(map[string]interface{}); ok {
+			s.add(itemPath, itemObj)
+		}
+	}
+}
+
+//lookup a value in the snapshot without locking
+//objects and lists are copied, so that the caller cannot change the snapshot
+func (s *snapshot) lookup(name string) (interface{}, error) {
+	v, ok := s.values[name]
+	if !ok {
+		//name may be written differently, e.g. with escapes
+		p, err := ParsePath(name)
+		if err != nil {
+			return nil, err
+		}
+		if v, ok = s.values[p.String()]; !ok {
+			return nil, fmt.Errorf("%s %w", name, ErrNotDefined)
+		}
+	}
+	return copyValue(v), nil
+}
+
+//lookupNamed returns the selected item of a named value that was registered
+//when frozen, ok=false for other names
+func (s *snapshot) lookupNamed(name string) (namedResult, bool) {
+	r, ok := s.named[name]
+	if !ok {
+		p, err := ParsePath(name)
+		if err != nil {
+			return namedResult{err: err}, true
+		}
+		if r, ok = s.named[p.String()]; !ok {
+			return namedResult{}, false
+		}
+	}
+	r.value = copyValue(r.value)
+	return r, true
+}
+
+//copyValue copies objects and lists, and the objects and lists in them
+func copyValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for n, item := range value {
+			copied[n] = copyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	}
+	//other lists and maps, e.g. []string from a default
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		reflect.Copy(copied, rv)
+		return copied.Interface()
+	case reflect.Map:
+		if rv.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return copied.Interface()
+	}
+	return v
+}
+
+//unfreeze is only used in tests, see export_test.go
+func unfreeze() {
+	frozen.Store((*snapshot)(nil))
+}
@@ -0,0 +1,172 @@
+package config_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+func TestFreeze(t *testing.T) {
+	config.SetDefault("frozen.http", map[string]interface{}{"address": "localhost", "port": 8000})
+	static.Add(map[string]interface{}{"frozen": map[string]interface{}{"http": map[string]interface{}{"port": 9000}, "name": "web", "hosts": []interface{}{"a", map[string]interface{}{"b": 1}}}})
+
+	t.Cleanup(config.Unfreeze)
+	if err := config.Freeze(); err != nil {
+		t.Fatalf("freeze failed: %v", err)
+	}
+	if !config.Frozen() {
+		t.Fatalf("not frozen")
+	}
+
+	//values from defaults and sources are resolved
+	if port, ok := config.GetInt("frozen.http.port"); !ok || port != 9000 {
+		t.Fatalf("port=%v,%v", port, ok)
+	}
+	if name, ok := config.GetString(`"frozen".name`); !ok || name != "web" {
+		t.Fatalf("name=%v,%v", name, ok)
+	}
+	obj, err := config.Lookup("frozen.http")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	obj.(map[string]interface{})["port"] = 1
+	if port, ok := config.GetInt("frozen.http.port"); !ok || port != 9000 {
+		t.Fatalf("changed snapshot port=%v,%v", port, ok)
+	}
+	hosts, err := config.Lookup("frozen.hosts")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	hosts.([]interface{})[0] = "changed"
+	hosts.([]interface{})[1].(map[string]interface{})["b"] = 2
+	if hosts, _ := config.Lookup("frozen.hosts"); !reflect.DeepEqual(hosts, []interface{}{"a", map[string]interface{}{"b": 1}}) {
+		t.Fatalf("changed snapshot hosts=%v", hosts)
+	}
+	if _, err := config.Lookup("frozen.unknown"); !errors.Is(err, config.ErrNotDefined) {
+		t.Fatalf("expected not defined, got %v", err)
+	}
+	if _, err := config.Lookup("frozen..x"); !errors.Is(err, config.ErrInvalidName) {
+		t.Fatalf("expected invalid name, got %v", err)
+	}
+
+	//no changes after freeze
+	if err := config.SetDefault("frozen.extra", 1); !errors.Is(err, config.ErrFrozen) {
+		t.Fatalf("expected frozen error, got %v", err)
+	}
+	if err := static.Add(map[string]interface{}{"extra": 1}); !errors.Is(err, config.ErrFrozen) {
+		t.Fatalf("expected frozen error, got %v", err)
+	}
+}
+
+type layeredConfig struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func TestFreezeLayered(t *testing.T) {
+	static.Add(map[string]interface{}{"freezelayer": map[string]interface{}{"x": 1}})
+	static.Add(map[string]interface{}{"freezelayer": map[string]interface{}{"y": 2}})
+	if v, err := config.GetStruct("freezelayer", layeredConfig{}); err != nil || v.(layeredConfig) != (layeredConfig{X: 1}) {
+		t.Fatalf("got %+v,%v", v, err)
+	}
+
+	//the object is already defined from the first source,
+	//so the value in the second source is shadowed
+	t.Cleanup(config.Unfreeze)
+	if err := config.Freeze(); err != nil {
+		t.Fatalf("freeze failed: %v", err)
+	}
+	if _, err := config.GetIntE("freezelayer.y"); !errors.Is(err, config.ErrNotDefined) {
+		t.Fatalf("expected shadowed value, got %v", err)
+	}
+	if x, err := config.GetIntE("freezelayer.x"); err != nil || x != 1 {
+		t.Fatalf("x=%v,%v", x, err)
+	}
+}
+
+func TestFreezeNamed(t *testing.T) {
+	templates := map[string]interface{}{"http": benchHTTPConfig{}}
+	static.Add(map[string]interface{}{"freezenamed": map[string]interface{}{"http": map[string]interface{}{"port": 1}}})
+	if _, _, err := config.GetNamedStruct("freezenamed", templates); err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	t.Cleanup(config.Unfreeze)
+	if err := config.Freeze(); err != nil {
+		t.Fatalf("freeze failed: %v", err)
+	}
+
+	//the item selected at the freeze is used
+	named, value, err := config.GetNamedStruct("freezenamed", templates)
+	if err != nil || named != "http" || value.(benchHTTPConfig) != (benchHTTPConfig{Address: "localhost", Port: 1}) {
+		t.Fatalf("got %s=%+v,%v", named, value, err)
+	}
+
+	//templates are not registered after the freeze
+	config.GetNamedStruct("freezenamedlate", templates)
+	if schema, _ := json.Marshal(config.JSONSchema()); strings.Contains(string(schema), "freezenamedlate") {
+		t.Fatalf("registered after freeze")
+	}
+}
+
+type benchHTTPConfig struct {
+	Address string `json:"address" default:"localhost"`
+	Port    int    `json:"port" validate:"min=1"`
+}
+
+//benchmarkGet calls get from parallel goroutines, with or without Freeze()
+func benchmarkGet(b *testing.B, freeze bool, get func() error) {
+	config.SetDefault("bench.port", 8000)
+	config.SetDefault("bench.http", map[string]interface{}{"port": 8080})
+	config.SetDefault("bench.named", map[string]interface{}{"http": map[string]interface{}{"port": 8080}})
+	if err := get(); err != nil {
+		b.Fatal(err)
+	}
+	if freeze {
+		b.Cleanup(config.Unfreeze)
+		if err := config.Freeze(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := get(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func lookupPort() error {
+	_, err := config.Lookup("bench.port")
+	return err
+}
+
+func getIntPort() error {
+	_, err := config.GetIntE("bench.port")
+	return err
+}
+
+func getHTTPStruct() error {
+	_, err := config.GetStruct("bench.http", benchHTTPConfig{})
+	return err
+}
+
+func getNamedHTTPStruct() error {
+	_, _, err := config.GetNamedStruct("bench.named", map[string]interface{}{"http": benchHTTPConfig{}})
+	return err
+}
+
+func BenchmarkLookupParallel(b *testing.B)               { benchmarkGet(b, false, lookupPort) }
+func BenchmarkLookupFrozenParallel(b *testing.B)         { benchmarkGet(b, true, lookupPort) }
+func BenchmarkGetIntParallel(b *testing.B)               { benchmarkGet(b, false, getIntPort) }
+func BenchmarkGetIntFrozenParallel(b *testing.B)         { benchmarkGet(b, true, getIntPort) }
+func BenchmarkGetStructParallel(b *testing.B)            { benchmarkGet(b, false, getHTTPStruct) }
+func BenchmarkGetStructFrozenParallel(b *testing.B)      { benchmarkGet(b, true, getHTTPStruct) }
+func BenchmarkGetNamedStructParallel(b *testing.B)       { benchmarkGet(b, false, getNamedHTTPStruct) }
+func BenchmarkGetNamedStructFrozenParallel(b *testing.B) { benchmarkGet(b, true, getNamedHTTPStruct) }
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+//Get is already the untyped getter, so the generic getter is called GetAs
+//all generic getters use the same conversion as GetInt(), GetStruct() etc
+//and call Validate() when T implements IValidator
+
+//GetAs is the typed form of Get()
+//e.g. port,ok := config.GetAs[int]("server.http.port")
+func GetAs[T any](name string) (T, bool) {
+	v, err := Bind[T](name)
+	if err != nil {
+		return v, false
+	}
+	return v, true
+}
+
+//MustGet is the same as GetAs() but panics when the value is not defined or invalid
+//use it only during initialisation
+func MustGet[T any](name string) T {
+	v, err := Bind[T](name)
+	if err != nil {
+		panic(fmt.Errorf("config: %v", err))
+	}
+	return v
+}
+
+//GetOr is the same as GetAs() but returns fallback when the value is not defined or invalid
+func GetOr[T any](name string, fallback T) T {
+	if v, err := Bind[T](name); err == nil {
+		return v
+	}
+	return fallback
+}
+
+//Bind returns the named value converted to T
+//it is the typed form of GetStruct(), but T may be any type
+//e.g. c,err := config.Bind[httpServerConfig]("server.http")
+func Bind[T any](name string, opts ...Option) (T, error) {
+	var v T
+	t := reflect.TypeOf(&v).Elem()
+	value, err := getAs(name, t, opts...)
+	if err != nil {
+		return v, err
+	}
+	if value != nil {
+		v = value.(T)
+	}
+	return v, nil
+}
@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+func TestGeneric(t *testing.T) {
+	config.SetDefault("generic.port", 8000)
+	static.Add(map[string]interface{}{
+		"generic": map[string]interface{}{
+			"limit": "10",
+			"http": map[string]interface{}{
+				"address": "myhost",
+				"port":    -1,
+			},
+		},
+	})
+
+	if port, ok := config.GetAs[int]("generic.port"); !ok || port != 8000 {
+		t.Fatalf("GetAs(generic.port)->%v,%v", port, ok)
+	}
+	if limit, ok := config.GetAs[int64]("generic.limit"); !ok || limit != 10 {
+		t.Fatalf("GetAs(generic.limit)->%v,%v", limit, ok)
+	}
+	if s := config.MustGet[string]("generic.port"); s != "8000" {
+		t.Fatalf("MustGet(generic.port)->%v", s)
+	}
+	if v := config.GetOr("generic.unknown", 3.5); v != 3.5 {
+		t.Fatalf("GetOr(generic.unknown)->%v", v)
+	}
+
+	//Bind validates: port -1 is invalid
+	if c, err := config.Bind[httpServerConfig]("generic.http"); err == nil {
+		t.Fatalf("Bind(generic.http)->%+v did not fail", c)
+	}
+	if c, err := config.Bind[*httpServerConfig]("generic.http"); err == nil {
+		t.Fatalf("Bind(generic.http)->%+v did not fail", c)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("MustGet(generic.unknown) did not panic")
+			}
+		}()
+		config.MustGet[int]("generic.unknown")
+	}()
+}
+
+func TestUnsigned(t *testing.T) {
+	static.Add(map[string]interface{}{
+		"unsigned": map[string]interface{}{
+			"max":      uint64(math.MaxUint64),
+			"text":     "18446744073709551615",
+			"negative": -1,
+			"api.example.com": map[string]interface{}{
+				"port": 443,
+			},
+		},
+	})
+	if v, ok := config.GetAs[uint64]("unsigned.max"); !ok || v != math.MaxUint64 {
+		t.Fatalf("GetAs(unsigned.max)->%v,%v", v, ok)
+	}
+	if v, ok := config.GetAs[uint64]("unsigned.text"); !ok || v != math.MaxUint64 {
+		t.Fatalf("GetAs(unsigned.text)->%v,%v", v, ok)
+	}
+	if v, ok := config.GetAs[uint]("unsigned.negative"); ok {
+		t.Fatalf("GetAs(unsigned.negative)->%v,%v", v, ok)
+	}
+
+	//quoted names report their source
+	if v, ok := config.GetAs[int](`unsigned."api.example.com".port`); !ok || v != 443 {
+		t.Fatalf("GetAs(port)->%v,%v", v, ok)
+	}
+	if source := config.SourceOf(`unsigned."api.example.com".port`); source != "static" {
+		t.Fatalf("source=%q", source)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"time"
+)
+
+//typed getters return ok=false when the value is not defined or cannot convert
+//to the type, see convert() for the parse rules
+//values that cannot convert are logged with the key and source that defined it
+//the Get*E() variants return the error instead, which may be ErrNotDefined,
+//ErrInvalidName or a *TypeError
+
+func GetInt(name string) (int, bool)   { return logged(GetIntE(name)) }
+func GetIntE(name string) (int, error) { return Bind[int](name) }
+
+func GetString(name string) (string, bool)   { return logged(GetStringE(name)) }
+func GetStringE(name string) (string, error) { return Bind[string](name) }
+
+//GetBool accepts true|false|1|0|t|f (see strconv.ParseBool)
+func GetBool(name string) (bool, bool)   { return logged(GetBoolE(name)) }
+func GetBoolE(name string) (bool, error) { return Bind[bool](name) }
+
+func GetFloat(name string) (float64, bool)   { return logged(GetFloatE(name)) }
+func GetFloatE(name string) (float64, error) { return Bind[float64](name) }
+
+//GetDuration accepts strings like "30s" or "1h30m", and numbers as nanoseconds
+func GetDuration(name string) (time.Duration, bool)   { return logged(GetDurationE(name)) }
+func GetDurationE(name string) (time.Duration, error) { return Bind[time.Duration](name) }
+
+//GetTime accepts RFC3339 strings, e.g. "2021-05-27T19:47:20Z"
+func GetTime(name string) (time.Time, bool)   { return logged(GetTimeE(name)) }
+func GetTimeE(name string) (time.Time, error) { return Bind[time.Time](name) }
+
+//GetByteSize accepts numbers and strings with units, e.g. "512MiB", see ByteSize
+func GetByteSize(name string) (ByteSize, bool)   { return logged(GetByteSizeE(name)) }
+func GetByteSizeE(name string) (ByteSize, error) { return Bind[ByteSize](name) }
+
+//GetURL accepts absolute URLs, i.e. with a scheme
+func GetURL(name string) (*url.URL, bool)   { return logged(GetURLE(name)) }
+func GetURLE(name string) (*url.URL, error) { return Bind[*url.URL](name) }
+
+func GetIP(name string) (net.IP, bool)   { return logged(GetIPE(name)) }
+func GetIPE(name string) (net.IP, error) { return Bind[net.IP](name) }
+
+//GetCIDR accepts a network like "10.0.0.0/8"
+func GetCIDR(name string) (*net.IPNet, bool)   { return logged(GetCIDRE(name)) }
+func GetCIDRE(name string) (*net.IPNet, error) { return Bind[*net.IPNet](name) }
+
+//GetStringSlice accepts a list or a comma separated string, e.g. "a,b,c"
+func GetStringSlice(name string) ([]string, bool)   { return logged(GetStringSliceE(name)) }
+func GetStringSliceE(name string) ([]string, error) { return Bind[[]string](name) }
+
+//GetStringMap accepts an object or a string of name=value pairs, e.g. "a=1,b=2"
+func GetStringMap(name string) (map[string]string, bool) { return logged(GetStringMapE(name)) }
+func GetStringMapE(name string) (map[string]string, error) {
+	return Bind[map[string]string](name)
+}
+
+//logged is used by the typed getters to return ok=false on error
+//invalid values are logged because the getters cannot return the reason
+func logged[T any](v T, err error) (T, bool) {
+	if err != nil {
+		if !errors.Is(err, ErrNotDefined) {
+			log.Errorf("%v", err)
+		}
+		return v, false
+	}
+	return v, true
+}
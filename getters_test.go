@@ -0,0 +1,105 @@
+package config_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+func TestTypedGetters(t *testing.T) {
+	static.Add(map[string]interface{}{
+		"typed": map[string]interface{}{
+			"int":      "1e3",
+			"float":    9000.0,
+			"bool":     "true",
+			"duration": "30s",
+			"time":     "2021-05-27T19:47:20Z",
+			"size":     "512MiB",
+			"url":      "https://api.example.com/v1",
+			"ip":       "10.0.0.1",
+			"cidr":     "10.0.0.0/8",
+			"list":     "a, b,c",
+			"map":      map[string]interface{}{"a": 1, "b": "two"},
+			"bad":      "abc",
+		},
+	})
+
+	if i, ok := config.GetInt("typed.int"); !ok || i != 1000 {
+		t.Fatalf("GetInt->%v,%v", i, ok)
+	}
+	if i, ok := config.GetInt("typed.float"); !ok || i != 9000 {
+		t.Fatalf("GetInt(float)->%v,%v", i, ok)
+	}
+	if f, ok := config.GetFloat("typed.float"); !ok || f != 9000 {
+		t.Fatalf("GetFloat->%v,%v", f, ok)
+	}
+	if b, ok := config.GetBool("typed.bool"); !ok || !b {
+		t.Fatalf("GetBool->%v,%v", b, ok)
+	}
+	if d, ok := config.GetDuration("typed.duration"); !ok || d != 30*time.Second {
+		t.Fatalf("GetDuration->%v,%v", d, ok)
+	}
+	if tm, ok := config.GetTime("typed.time"); !ok || tm.Unix() != 1622144840 {
+		t.Fatalf("GetTime->%v,%v", tm, ok)
+	}
+	if s, ok := config.GetByteSize("typed.size"); !ok || s != 512*1024*1024 || s.String() != "512MiB" {
+		t.Fatalf("GetByteSize->%v,%v", s, ok)
+	}
+	if u, ok := config.GetURL("typed.url"); !ok || u.Host != "api.example.com" {
+		t.Fatalf("GetURL->%v,%v", u, ok)
+	}
+	if ip, ok := config.GetIP("typed.ip"); !ok || ip.String() != "10.0.0.1" {
+		t.Fatalf("GetIP->%v,%v", ip, ok)
+	}
+	if n, ok := config.GetCIDR("typed.cidr"); !ok || n.String() != "10.0.0.0/8" {
+		t.Fatalf("GetCIDR->%v,%v", n, ok)
+	}
+	if l, ok := config.GetStringSlice("typed.list"); !ok || len(l) != 3 || l[0] != "a" || l[1] != "b" || l[2] != "c" {
+		t.Fatalf("GetStringSlice->%q,%v", l, ok)
+	}
+	if m, ok := config.GetStringMap("typed.map"); !ok || len(m) != 2 || m["a"] != "1" || m["b"] != "two" {
+		t.Fatalf("GetStringMap->%v,%v", m, ok)
+	}
+
+	//invalid values
+	if i, ok := config.GetInt("typed.bad"); ok {
+		t.Fatalf("GetInt(bad)->%v,%v", i, ok)
+	}
+	if d, ok := config.GetDuration("typed.bad"); ok {
+		t.Fatalf("GetDuration(bad)->%v,%v", d, ok)
+	}
+	if u, ok := config.GetURL("typed.bad"); ok {
+		t.Fatalf("GetURL(bad)->%v,%v", u, ok)
+	}
+	if s, ok := config.GetByteSize("typed.bad"); ok {
+		t.Fatalf("GetByteSize(bad)->%v,%v", s, ok)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]config.ByteSize{
+		"0":                      0,
+		"1024":                   1024,
+		"1KB":                    1000,
+		"1kib":                   1024,
+		"1.5KiB":                 1536,
+		"2 GB":                   2000000000,
+		"18446744073709551615":   math.MaxUint64,
+		"16383PiB":               16383 << 50,
+		"16383.5PiB":             16383<<50 + 1<<49,
+		"18446744073709549568.0": 18446744073709549568, //largest float64 below 2^64
+	}
+	for s, expected := range tests {
+		if size, err := config.ParseByteSize(s); err != nil || size != expected {
+			t.Fatalf("ParseByteSize(%s)->%v,%v", s, size, err)
+		}
+	}
+	for _, s := range []string{"", "MiB", "1.5", "1XB", "-1", "18446744073709551616", "16384PiB", "16384.0PiB", "18446744073709551616.0"} {
+		if size, err := config.ParseByteSize(s); err == nil {
+			t.Fatalf("ParseByteSize(%s)->%v did not fail", s, size)
+		}
+	}
+}
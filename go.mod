@@ -1,8 +1,9 @@
 module github.com/stewelarend/config
 
-go 1.16
+go 1.18
 
 require (
 	github.com/stewelarend/logger v0.0.2-0.20210527194720-308ba4de2f2f
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,87 @@
+package config
+
+import "context"
+
+//Defaults returns a copy of all default values set in the code
+func Defaults() map[string]interface{} {
+	return defaults.Value()
+}
+
+//Defined returns a copy of all values used so far, see Lookup()
+func Defined() map[string]interface{} {
+	return defined.Value()
+}
+
+//SourceOf returns the name of the source that defined a value,
+//"defaults" for default values, or "" when not yet defined
+func SourceOf(name string) string {
+	return sourceOf(name)
+}
+
+//Sources returns the names of the sources in the order they are used
+func Sources() []string {
+	list := sourceList()
+	names := make([]string, len(list))
+	for i, e := range list {
+		names[i] = sourceName(e.source)
+	}
+	return names
+}
+
+//Resolved returns the full config tree: the defaults, with each leaf value
+//from the first source that has it, and the values already defined
+//only sources that list their keys (IKeysSource, IContextSource) are included, plus any
+//values already defined from other sources, e.g. env
+func Resolved() map[string]interface{} {
+	resolved, _ := resolve()
+	return resolved.Value()
+}
+
+//resolve returns the resolved tree, and the source name of each leaf value
+func resolve() (*values, map[string]string) {
+	list := sourceList()
+
+	resolved := NewValues("resolved", defaults.Value())
+	leafSources := map[string]string{}
+	for _, key := range resolved.Keys() {
+		leafSources[key] = "defaults"
+	}
+	//last source first, so that earlier sources replace its values
+	for i := len(list) - 1; i >= 0; i-- {
+		setLeaves(resolved, leafSources, list[i])
+	}
+	for _, key := range defined.Keys() {
+		value, _ := defined.Get(key)
+		setLeaf(resolved, leafSources, sourceOf(key), key, value)
+	}
+	return resolved, leafSources
+} //resolve()
+
+//setLeaves sets all leaf values from a source in v
+//the source is called within its timeout, like for lookups
+func setLeaves(v *values, leafSources map[string]string, e sourceEntry) {
+	keys, _, err := e.keys(context.Background())
+	if err != nil {
+		log.Errorf("%v", err)
+		return
+	}
+	for _, key := range keys {
+		value, ok, err := e.get(context.Background(), key)
+		if err != nil {
+			log.Errorf("%v", err)
+			continue
+		}
+		if ok {
+			setLeaf(v, leafSources, sourceName(e.source), key, value)
+		}
+	}
+}
+
+func setLeaf(v *values, leafSources map[string]string, source string, key string, value interface{}) {
+	v.Del(key)
+	if err := v.Set(key, value); err != nil {
+		log.Debugf("cannot resolve %s: %v", key, err)
+		return
+	}
+	leafSources[key] = source
+}
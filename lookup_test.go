@@ -0,0 +1,152 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stewelarend/config"
+)
+
+//slowSource answers only names starting with "slowsrc."
+//"slowsrc.value" takes a while, "slowsrc.hang" blocks until released
+type slowSource struct {
+	calls   int32
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *slowSource) Get(name string) (interface{}, bool) {
+	if !strings.HasPrefix(name, "slowsrc.") {
+		return nil, false
+	}
+	switch name {
+	case "slowsrc.value":
+		atomic.AddInt32(&s.calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return 1, true
+	case "slowsrc.shared":
+		time.Sleep(50 * time.Millisecond)
+		return 3, true
+	case "slowsrc.hang":
+		s.entered <- struct{}{}
+		<-s.release
+		return 2, true
+	}
+	return nil, false
+}
+
+func (s *slowSource) String() string { return "slow" }
+
+//hangKeysSource lists its keys, and "hangkeys.port" blocks until released
+type hangKeysSource struct {
+	release chan struct{}
+}
+
+func (s hangKeysSource) Get(name string) (interface{}, bool) {
+	switch name {
+	case "hangkeys.port":
+		<-s.release
+		return 8000, true
+	case "hangkeys.name":
+		return "web", true
+	}
+	return nil, false
+}
+
+func (hangKeysSource) Keys() []string { return []string{"hangkeys.name", "hangkeys.port"} }
+
+func (hangKeysSource) String() string { return "hangkeys" }
+
+func TestResolvedTimeout(t *testing.T) {
+	s := hangKeysSource{release: make(chan struct{})}
+	t.Cleanup(func() { close(s.release) })
+	if err := config.AddSource(s, config.WithSourceTimeout(50*time.Millisecond)); err != nil {
+		t.Fatalf("add source failed: %v", err)
+	}
+
+	//the value that does not respond in time is left out
+	resolved := make(chan map[string]interface{}, 1)
+	go func() {
+		resolved <- config.Resolved()
+	}()
+	select {
+	case r := <-resolved:
+		hangKeys, _ := r["hangkeys"].(map[string]interface{})
+		if _, ok := hangKeys["port"]; ok || hangKeys["name"] != "web" {
+			t.Fatalf("resolved hangkeys=%v", hangKeys)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("resolved did not return")
+	}
+}
+
+func TestLookupConcurrent(t *testing.T) {
+	s := &slowSource{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(s.release)
+	if err := config.AddSource(s, config.WithSourceTimeout(200*time.Millisecond)); err != nil {
+		t.Fatalf("add source failed: %v", err)
+	}
+	config.SetDefault("slowother.port", 8000)
+
+	//a source that does not respond in time fails the lookup,
+	//and does not block lookups of other names meanwhile
+	errs := make(chan error, 1)
+	go func() {
+		_, err := config.Lookup("slowsrc.hang")
+		errs <- err
+	}()
+	<-s.entered
+	if port, ok := config.GetInt("slowother.port"); !ok || port != 8000 {
+		t.Fatalf("port=%v,%v", port, ok)
+	}
+	err := <-errs
+	var sourceErr *config.SourceError
+	if !errors.As(err, &sourceErr) || sourceErr.Source != "slow" || sourceErr.Key != "slowsrc.hang" {
+		t.Fatalf("expected source error, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	//concurrent lookups of the same name call the source once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := config.Lookup("slowsrc.value"); err != nil || v != 1 {
+				t.Errorf("value=%v,%v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls := atomic.LoadInt32(&s.calls); calls != 1 {
+		t.Fatalf("source called %d times", calls)
+	}
+
+	//a caller that stops waiting does not fail the others
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer shortCancel()
+	go func() {
+		_, err := config.LookupContext(shortCtx, "slowsrc.shared")
+		errs <- err
+	}()
+	if v, err := config.Lookup("slowsrc.shared"); err != nil || v != 3 {
+		t.Fatalf("shared=%v,%v", v, err)
+	}
+	if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	//the caller's context also limits the lookup
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := config.LookupContext(ctx, "slowsrc.other"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//INamedSource is implemented by sources that can answer named lookups
+//natively, e.g. when the selected item is stored separately from its config
+//LookupNamed() uses it instead of Get() for the source
+type INamedSource interface {
+	//get a named item and its value
+	//e.g. with config {"rpc":{"server":{"http":{"port":8000}}}}
+	//     GetNamed("rpc.server") will return:
+	//			named="http"
+	//			value={"port":8000}
+	GetNamed(name string) (named string, value interface{}, ok bool)
+}
+
+//discriminators are the discriminator fields of named values
+var discriminators = map[string]string{}
+
+//SetDiscriminator allows a named value to select its item with a field
+//instead of the single-key form, e.g. after SetDiscriminator("server", "type")
+//both of these select the "http" item with port 8000:
+//	{"server":{"http":{"port":8000}}}
+//	{"server":{"type":"http","port":8000}}
+func SetDiscriminator(name string, field string) error {
+	if _, err := ParsePath(name); err != nil {
+		return err
+	}
+	if field == "" {
+		return fmt.Errorf("cannot set empty discriminator for %s", name)
+	}
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	discriminators[name] = field
+	return nil
+}
+
+//namedSourceOf returns the INamedSource of a source, also when it is
+//added with AddContextSource(), or nil
+func namedSourceOf(s ISource) INamedSource {
+	if legacy, ok := s.(legacySource); ok {
+		if adapted, ok := legacy.IContextSource.(contextSource); ok {
+			return namedSourceOf(adapted.ISource)
+		}
+		namedSource, _ := legacy.IContextSource.(INamedSource)
+		return namedSource
+	}
+	namedSource, _ := s.(INamedSource)
+	return namedSource
+}
+
+//hasDiscriminator is true when obj selects its item with the discriminator field
+func hasDiscriminator(name string, obj map[string]interface{}) bool {
+	field := discriminatorOf(name)
+	if field == "" {
+		return false
+	}
+	_, ok := obj[field]
+	return ok
+}
+
+func discriminatorOf(name string) string {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	return discriminators[name]
+}
+
+//LookupNamed gets the selected item of a named value and its value, e.g.
+//with config {"server":{"http":{"port":8000}}}
+//	LookupNamed("server") returns "http", {"port":8000}
+//it fails with ErrNotDefined when no item is selected, also when only
+//defaults of several items are defined, and with an error naming the items
+//when several items are configured
+func LookupNamed(name string) (string, interface{}, error) {
+	//after Freeze(), registered named values are selected without locking
+	if s := frozenSnapshot(); s != nil {
+		if r, ok := s.lookupNamed(name); ok {
+			return r.named, r.value, r.err
+		}
+	}
+	value, err := lookup(context.Background(), name, true)
+	if err != nil {
+		return "", nil, err
+	}
+	return namedItem(name, value)
+}
+
+//namedItem returns the selected item in the value of a named config
+func namedItem(name string, value interface{}) (string, interface{}, error) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return "", nil, &TypeError{Key: name, Want: "object with a named item", Got: value, Source: sourceOf(name)}
+	}
+	if field := discriminatorOf(name); field != "" {
+		if named, ok := obj[field]; ok {
+			namedString, ok := named.(string)
+			if !ok || namedString == "" {
+				return "", nil, &TypeError{Key: name + "." + quoteSegment(field), Want: "item name", Got: named, Source: sourceOf(name)}
+			}
+			return namedString, discriminatedItem(name, field, namedString, obj), nil
+		}
+	}
+	items := sortedKeys(obj)
+	if len(items) == 1 {
+		return items[0], obj[items[0]], nil
+	}
+	//items with only defaults are not configured
+	configured := []string{}
+	for _, item := range items {
+		if configuredItem(name + "." + quoteSegment(item)) {
+			configured = append(configured, item)
+		}
+	}
+	if len(configured) == 1 {
+		return configured[0], obj[configured[0]], nil
+	}
+	if len(configured) == 0 {
+		return "", nil, fmt.Errorf("%s item %w, configure one of %s", name, ErrNotDefined, strings.Join(namedOptions(name, items), ", "))
+	}
+	return "", nil, fmt.Errorf("%s has %d items configured (%s), expecting only one", name, len(configured), strings.Join(configured, ", "))
+} //namedItem()
+
+//configuredItem is true when the item or any of its values came from a source
+func configuredItem(name string) bool {
+	if source := sourceOf(name); source != "" {
+		return source != "defaults"
+	}
+	sourcesMutex.Lock()
+	defer sourcesMutex.Unlock()
+	for key, source := range definedSources {
+		if strings.HasPrefix(key, name+".") && source != "defaults" {
+			return true
+		}
+	}
+	return false
+}
+
+//discriminatedItem returns the value of the item selected with a discriminator,
+//i.e. the other fields merged into the defaults of the item
+func discriminatedItem(name, field, named string, obj map[string]interface{}) map[string]interface{} {
+	options := map[string]bool{}
+	for _, option := range namedOptions(name, nil) {
+		options[option] = true
+	}
+	item := map[string]interface{}{}
+	for n, v := range obj {
+		if n == field || options[n] {
+			continue //discriminator or default of an item
+		}
+		item[n] = v
+	}
+	if defaultValue, ok := defaults.Get(name + "." + quoteSegment(named)); ok {
+		if defaultObj, ok := defaultValue.(map[string]interface{}); ok {
+			item = mergedObj(defaultObj, item)
+		}
+	}
+	return item
+}
+
+//namedOptions returns the names of the registered templates and items
+//with defaults of a named value, or else the given items
+func namedOptions(name string, items []string) []string {
+	options := map[string]bool{}
+	for named := range registeredNamed()[name] {
+		options[named] = true
+	}
+	if defaultValue, ok := defaults.Get(name); ok {
+		if defaultObj, ok := defaultValue.(map[string]interface{}); ok {
+			for named, v := range defaultObj {
+				if v != nil && (reflect.TypeOf(v).Kind() == reflect.Map || reflect.TypeOf(v).Kind() == reflect.Struct) {
+					options[named] = true //item defaults, not a scalar field
+				}
+			}
+		}
+	}
+	if len(options) == 0 {
+		return items
+	}
+	return sortedKeys(options)
+}
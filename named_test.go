@@ -0,0 +1,160 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+type namedHTTPConfig struct {
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+}
+
+type namedZMQConfig struct {
+	Address string `json:"address"`
+}
+
+//zmqSource answers the named lookup of "nativenamed" without a value for Get()
+type zmqSource struct{}
+
+func (zmqSource) Get(name string) (interface{}, bool) { return nil, false }
+
+func (zmqSource) GetNamed(name string) (string, interface{}, bool) {
+	if name != "nativenamed" {
+		return "", nil, false
+	}
+	return "zmq", map[string]interface{}{"address": "tcp://*:5555"}, true
+}
+
+//hangSource blocks the named lookup of "hangnamed" until released
+type hangSource struct {
+	release chan struct{}
+}
+
+func (hangSource) Get(name string) (interface{}, bool) { return nil, false }
+
+func (s hangSource) GetNamed(name string) (string, interface{}, bool) {
+	if name != "hangnamed" {
+		return "", nil, false
+	}
+	<-s.release
+	return "http", map[string]interface{}{}, true
+}
+
+//ctxNamedSource is a context source that answers the named lookup of "ctxnamed"
+type ctxNamedSource struct{}
+
+func (ctxNamedSource) Get(ctx context.Context, name string) (interface{}, bool, error) {
+	return nil, false, nil
+}
+
+func (ctxNamedSource) Keys(ctx context.Context, prefix string) ([]string, error) {
+	return nil, config.ErrNotSupported
+}
+
+func (ctxNamedSource) Close() error { return nil }
+
+func (ctxNamedSource) GetNamed(name string) (string, interface{}, bool) {
+	if name != "ctxnamed" {
+		return "", nil, false
+	}
+	return "http", map[string]interface{}{"port": 7000}, true
+}
+
+func TestLookupNamed(t *testing.T) {
+	templates := map[string]interface{}{"http": namedHTTPConfig{}, "zmq": namedZMQConfig{}}
+
+	//only defaults of both items, none selected
+	config.SetDefault("unselected.http", namedHTTPConfig{Port: 8000})
+	config.SetDefault("unselected.zmq", namedZMQConfig{})
+	_, _, err := config.LookupNamed("unselected")
+	if !errors.Is(err, config.ErrNotDefined) || !strings.Contains(err.Error(), "configure one of http, zmq") {
+		t.Fatalf("expected not defined, got %v", err)
+	}
+
+	//single-key form with defaults of each item
+	config.SetDefaultStruct("svc.http", namedHTTPConfig{Address: "localhost", Port: 8000})
+	config.SetDefaultStruct("svc.zmq", namedZMQConfig{Address: "tcp://*:5555"})
+	static.Add(map[string]interface{}{"svc": map[string]interface{}{"zmq": map[string]interface{}{"address": "tcp://*:7000"}}})
+	if named, value, err := config.GetNamedStruct("svc", templates); err != nil || named != "zmq" || value.(namedZMQConfig).Address != "tcp://*:7000" {
+		t.Fatalf("got %s=%+v,%v", named, value, err)
+	}
+	//still selected after values of the other item are used
+	if port, ok := config.GetInt("svc.http.port"); !ok || port != 8000 {
+		t.Fatalf("port=%v,%v", port, ok)
+	}
+	if named, _, err := config.LookupNamed("svc"); err != nil || named != "zmq" {
+		t.Fatalf("got %s,%v", named, err)
+	}
+
+	//several items configured
+	static.Add(map[string]interface{}{"several": map[string]interface{}{"http": map[string]interface{}{}, "zmq": map[string]interface{}{}}})
+	if _, _, err := config.LookupNamed("several"); err == nil || err.Error() != "several has 2 items configured (http, zmq), expecting only one" {
+		t.Fatalf("expected several items, got %v", err)
+	}
+	if _, _, err := config.GetNamedStruct("several", templates); err == nil {
+		t.Fatalf("got struct from several items")
+	}
+
+	//item selected with a discriminator, merged with its defaults
+	if err := config.SetDiscriminator("discriminated", "type"); err != nil {
+		t.Fatalf("set discriminator failed: %v", err)
+	}
+	config.SetDefaultStruct("discriminated.http", namedHTTPConfig{Address: "localhost", Port: 8000})
+	config.SetDefaultStruct("discriminated.zmq", namedZMQConfig{Address: "tcp://*:5555"})
+	static.Add(map[string]interface{}{"discriminated": map[string]interface{}{"type": "http", "port": 9000}})
+	named, value, err := config.GetNamedStruct("discriminated", templates)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if named != "http" || value.(namedHTTPConfig) != (namedHTTPConfig{Address: "localhost", Port: 9000}) {
+		t.Fatalf("got %s=%+v", named, value)
+	}
+
+	//the single-key form still works with a discriminator
+	if err := config.SetDiscriminator("keyed", "type"); err != nil {
+		t.Fatalf("set discriminator failed: %v", err)
+	}
+	static.Add(map[string]interface{}{"keyed": map[string]interface{}{"zmq": map[string]interface{}{"address": "tcp://*:6000"}}})
+	if named, value, err := config.GetNamedStruct("keyed", templates); err != nil || named != "zmq" || value.(namedZMQConfig).Address != "tcp://*:6000" {
+		t.Fatalf("got %s=%+v,%v", named, value, err)
+	}
+
+	//invalid templates are reported
+	if _, _, err := config.GetNamedStruct("keyed", map[string]interface{}{"zmq": nil}); err == nil || !strings.Contains(err.Error(), "nil template") {
+		t.Fatalf("expected nil template error, got %v", err)
+	}
+
+	//source that answers named lookups natively
+	config.AddSource(zmqSource{})
+	if named, value, err := config.GetNamedStruct("nativenamed", templates); err != nil || named != "zmq" || value.(namedZMQConfig).Address != "tcp://*:5555" {
+		t.Fatalf("got %s=%+v,%v", named, value, err)
+	}
+
+	//also when added as a context source
+	if err := config.AddContextSource(ctxNamedSource{}); err != nil {
+		t.Fatalf("add source failed: %v", err)
+	}
+	if named, value, err := config.LookupNamed("ctxnamed"); err != nil || named != "http" || !reflect.DeepEqual(value, map[string]interface{}{"port": 7000}) {
+		t.Fatalf("got %s=%+v,%v", named, value, err)
+	}
+
+	//and within the timeout of the source
+	hang := hangSource{release: make(chan struct{})}
+	defer close(hang.release)
+	if err := config.AddSource(hang, config.WithSourceTimeout(50*time.Millisecond)); err != nil {
+		t.Fatalf("add source failed: %v", err)
+	}
+	_, _, err = config.LookupNamed("hangnamed")
+	var sourceErr *config.SourceError
+	if !errors.As(err, &sourceErr) || !errors.Is(err, context.DeadlineExceeded) || sourceErr.Key != "hangnamed" {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+}
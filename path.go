@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Path is a config name split into its segments
+//it is used to build names for keys that cannot be written in plain dotted notation,
+//e.g. hostnames or header names that contain '.' or other special characters:
+//	config.NewPath("hosts", "api.example.com", "timeout").String()
+//	-> hosts."api.example.com".timeout
+//the resulting string is accepted by all functions that take a config name
+type Path []string
+
+func NewPath(segments ...string) Path {
+	p := Path{}
+	return p.Add(segments...)
+}
+
+//Add returns a new path with the segments appended
+//segments are literal keys and are quoted in String() when required
+func (p Path) Add(segments ...string) Path {
+	newPath := make(Path, 0, len(p)+len(segments))
+	newPath = append(newPath, p...)
+	newPath = append(newPath, segments...)
+	return newPath
+}
+
+//String returns the path in dotted notation,
+//quoting segments that are not plain names
+func (p Path) String() string {
+	s := make([]string, len(p))
+	for i, segment := range p {
+		s[i] = quoteSegment(segment)
+	}
+	return strings.Join(s, ".")
+}
+
+func quoteSegment(segment string) string {
+	if nameRegex.MatchString(segment) {
+		return segment
+	}
+	quoted := strings.ReplaceAll(segment, `\`, `\\`)
+	quoted = strings.ReplaceAll(quoted, `"`, `\"`)
+	return `"` + quoted + `"`
+}
+
+//ParsePath splits a config name into segments
+//segments are separated with '.' and each segment is either:
+//	- a plain name matching namePattern, e.g. server
+//	- a quoted name that may contain any characters, e.g. "api.example.com"
+//	  (use \" and \\ to write '"' and '\' inside the quotes)
+//	- an unquoted name with backslash escapes, e.g. api\.example\.com
+func ParsePath(name string) (Path, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w (missing name)", ErrInvalidName)
+	}
+	p := Path{}
+	i := 0
+	for {
+		segment, next, err := parseSegment(name, i)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, segment)
+		if next == len(name) {
+			return p, nil
+		}
+		//parseSegment only stops at '.' or end of name
+		i = next + 1
+	}
+}
+
+//parseSegment parses the segment starting at name[start]
+//and returns the segment with the index where it ended ('.' or len(name))
+func parseSegment(name string, start int) (string, int, error) {
+	if start < len(name) && name[start] == '"' {
+		segment := strings.Builder{}
+		for i := start + 1; i < len(name); i++ {
+			switch name[i] {
+			case '\\':
+				if i+1 >= len(name) {
+					return "", 0, fmt.Errorf("%w \"%s\" (escape at end)", ErrInvalidName, name)
+				}
+				i++
+				segment.WriteByte(name[i])
+			case '"':
+				if segment.Len() == 0 {
+					return "", 0, fmt.Errorf("%w \"%s\" (empty quoted segment)", ErrInvalidName, name)
+				}
+				if i+1 < len(name) && name[i+1] != '.' {
+					return "", 0, fmt.Errorf("%w \"%s\" (expecting '.' after quoted segment)", ErrInvalidName, name)
+				}
+				return segment.String(), i + 1, nil
+			default:
+				segment.WriteByte(name[i])
+			}
+		}
+		return "", 0, fmt.Errorf("%w \"%s\" (missing closing quote)", ErrInvalidName, name)
+	}
+
+	segment := strings.Builder{}
+	escaped := false
+	i := start
+	for ; i < len(name) && name[i] != '.'; i++ {
+		if name[i] == '\\' {
+			if i+1 >= len(name) {
+				return "", 0, fmt.Errorf("%w \"%s\" (escape at end)", ErrInvalidName, name)
+			}
+			escaped = true
+			i++
+		}
+		segment.WriteByte(name[i])
+	}
+	if segment.Len() == 0 {
+		return "", 0, fmt.Errorf("%w \"%s\" (empty segment)", ErrInvalidName, name)
+	}
+	if !escaped && !nameRegex.MatchString(segment.String()) {
+		return "", 0, fmt.Errorf("%w \"%s\" in \"%s\"", ErrInvalidName, segment.String(), name)
+	}
+	return segment.String(), i, nil
+}
@@ -0,0 +1,63 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stewelarend/config"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := map[string][]string{
+		"a":                               {"a"},
+		"a.b.c":                           {"a", "b", "c"},
+		`hosts."api.example.com".timeout`: {"hosts", "api.example.com", "timeout"},
+		`hosts.api\.example\.com.timeout`: {"hosts", "api.example.com", "timeout"},
+		`headers."X-Say \"hi\""`:          {"headers", `X-Say "hi"`},
+		`"a\\b"`:                          {`a\b`},
+	}
+	for name, expected := range tests {
+		p, err := config.ParsePath(name)
+		if err != nil {
+			t.Fatalf("ParsePath(%s) failed: %v", name, err)
+		}
+		if len(p) != len(expected) {
+			t.Fatalf("ParsePath(%s)->%q != %q", name, p, expected)
+		}
+		for i := range p {
+			if p[i] != expected[i] {
+				t.Fatalf("ParsePath(%s)->%q != %q", name, p, expected)
+			}
+		}
+		//string must parse back to the same path
+		if p2, err := config.ParsePath(p.String()); err != nil || p2.String() != p.String() {
+			t.Fatalf("ParsePath(%s).String()->%s does not parse back: %v", name, p.String(), err)
+		}
+	}
+
+	for _, name := range []string{"", ".", "a.", ".a", "a..b", `"a`, `""`, `"a"b`, "1a", "a b", `a\`} {
+		if p, err := config.ParsePath(name); err == nil {
+			t.Fatalf("ParsePath(%s)->%q did not fail", name, p)
+		}
+	}
+}
+
+func TestQuotedKeys(t *testing.T) {
+	values := config.NewValues("test", map[string]interface{}{
+		"hosts": map[string]interface{}{
+			"api.example.com": map[string]interface{}{"timeout": 10},
+		},
+	})
+	timeout, ok := values.Get(config.NewPath("hosts", "api.example.com", "timeout").String())
+	if !ok || timeout != 10 {
+		t.Fatalf("get timeout->%v,%v", timeout, ok)
+	}
+	if v, ok := values.Get("hosts.api.example.com.timeout"); ok {
+		t.Fatalf("got unquoted name with value %v", v)
+	}
+	if err := values.Set(`headers."Content-Type"`, "text/plain"); err != nil {
+		t.Fatalf("failed to set quoted name: %v", err)
+	}
+	if v, ok := values.Get(`headers.Content-Type`); !ok || v != "text/plain" {
+		t.Fatalf("get header->%v,%v", v, ok)
+	}
+}
@@ -0,0 +1,63 @@
+package config_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+)
+
+type startupDBConfig struct {
+	Host string `json:"host" required:"true"`
+	Port int    `json:"port" default:"5432" validate:"min=1"`
+}
+
+func TestRegisterValidate(t *testing.T) {
+	if err := config.Register("startup.db", startupDBConfig{}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if err := config.Register("startup.cache", startupDBConfig{}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if err := config.Register("startup.multi", startupDBConfig{}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if err := config.Register("startup.bad", &ruledServerConfig{}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if err := config.Register("startup..x", startupDBConfig{}); !errors.Is(err, config.ErrInvalidName) {
+		t.Fatalf("expected invalid name, got %v", err)
+	}
+	config.SetDefault("startup.db", map[string]interface{}{"host": "db", "port": "abc"})
+	config.SetDefault("startup.multi", map[string]interface{}{"port": "abc"})
+	config.SetDefault("startup.bad", map[string]interface{}{"scheme": "ftp", "host": "x", "port": 0})
+
+	err := config.Validate()
+	var errs config.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	//other tests also register, only look at these keys
+	msgs := []string{}
+	for _, e := range errs {
+		if strings.HasPrefix(e.Path, "startup.") {
+			msgs = append(msgs, e.Error())
+		}
+	}
+	expected := []string{
+		`startup.bad.scheme: "ftp" is not one of http, https`,
+		`startup.bad.port: 0 is less than 1`,
+		`startup.bad.timeout: 0s is less than 1s`,
+		`startup.bad.proxy: "" is not a valid URL`,
+		`startup.bad.name: length 0 is less than 3`,
+		`startup.bad.name: "" does not match ^[a-z,]+$`,
+		`startup.cache.host: is required but not defined`,
+		`startup.db.port: expected int, got "abc" (from defaults)`,
+		`startup.multi.host: is required but not defined`,
+		`startup.multi.port: expected int, got "abc" (from defaults)`,
+	}
+	if strings.Join(msgs, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("errors:\n%s\nexpected:\n%s", strings.Join(msgs, "\n"), strings.Join(expected, "\n"))
+	}
+}
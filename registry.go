@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stewelarend/logger"
+)
+
+//registry of struct types that describe config values
+//used by GetStruct(name, nil) and for documentation
+var (
+	registryMutex sync.Mutex
+	registered    = map[string]reflect.Type{}
+	namedTypes    = map[string]map[string]reflect.Type{} //name -> named -> type
+	owners        = map[string]string{}                  //name -> package that set the default or registered it
+)
+
+//Register the type of config value for name, without setting a default, e.g.:
+//	config.Register("server.http", httpServerConfig{})
+//registered names are checked by Validate() at startup
+//and GetStruct(name, nil) returns the registered type
+func Register(name string, tmpl interface{}) error {
+	p, err := ParsePath(name)
+	if err != nil {
+		return err
+	}
+	t := reflect.TypeOf(tmpl)
+	if t == nil {
+		return fmt.Errorf("cannot register %s with nil template", name)
+	}
+	registerType(name, t)
+	setOwner(p, logger.GetCaller(2))
+	return nil
+}
+
+func registerType(name string, t reflect.Type) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registered[name] = t
+}
+
+//registeredType returns the type registered for name
+func registeredType(name string) (reflect.Type, bool) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	t, ok := registered[name]
+	return t, ok
+}
+
+//registeredNames returns the registered names, sorted
+func registeredNames() []string {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//RegisterNamed registers the templates of a named config value, see GetNamedStruct(), e.g.:
+//	config.RegisterNamed("server", map[string]interface{}{"http": httpServerConfig{}, "zmq": zmqServerConfig{}})
+//GetNamedStruct() also registers its templates when called
+func RegisterNamed(name string, templates map[string]interface{}) error {
+	return registerNamed(name, templates, logger.GetCaller(2))
+}
+
+func registerNamed(name string, templates map[string]interface{}, caller logger.Caller) error {
+	p, err := ParsePath(name)
+	if err != nil {
+		return err
+	}
+	types := map[string]reflect.Type{}
+	for named, tmpl := range templates {
+		t := reflect.TypeOf(tmpl)
+		if t == nil {
+			return fmt.Errorf("cannot register %s.%s with nil template", name, quoteSegment(named))
+		}
+		types[named] = t
+	}
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if namedTypes[name] == nil {
+		namedTypes[name] = map[string]reflect.Type{}
+	}
+	for named, t := range types {
+		namedTypes[name][named] = t
+	}
+	if _, ok := owners[p.String()]; !ok {
+		owners[p.String()] = callerPackage(caller)
+	}
+	return nil
+} //registerNamed()
+
+//namedTemplates holds the keys of templates registered by GetNamedStruct(),
+//so that it registers them on the first call only and reads do not lock the registry
+var namedTemplates sync.Map
+
+//templatesKey identifies a name with its templates, see namedTemplates
+func templatesKey(name string, templates map[string]interface{}) (string, error) {
+	items := make([]string, 0, len(templates))
+	for named, tmpl := range templates {
+		t := reflect.TypeOf(tmpl)
+		if t == nil {
+			return "", fmt.Errorf("cannot register %s.%s with nil template", name, quoteSegment(named))
+		}
+		items = append(items, quoteSegment(named)+"="+t.PkgPath()+"."+t.String())
+	}
+	sort.Strings(items)
+	return name + " " + strings.Join(items, ","), nil
+}
+
+//registeredNamed returns a copy of the registered named templates
+func registeredNamed() map[string]map[string]reflect.Type {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	copied := map[string]map[string]reflect.Type{}
+	for name, types := range namedTypes {
+		copied[name] = map[string]reflect.Type{}
+		for named, t := range types {
+			copied[name][named] = t
+		}
+	}
+	return copied
+}
+
+//unregister removes the registered type and owner of name, used by tests to clean up
+func unregister(name string) {
+	p, err := ParsePath(name)
+	if err != nil {
+		return
+	}
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	delete(registered, name)
+	delete(owners, p.String())
+}
+
+//setOwner records the package of the caller as the owner of the value
+//keyed by the canonical path, as searched by ownerOf()
+func setOwner(p Path, caller logger.Caller) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	owners[p.String()] = callerPackage(caller)
+}
+
+//ownerOf returns the package that set the default or registered name or its parent
+func ownerOf(name string) string {
+	p, err := ParsePath(name)
+	if err != nil {
+		return ""
+	}
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	for i := len(p); i > 0; i-- {
+		if owner, ok := owners[p[:i].String()]; ok {
+			return owner
+		}
+	}
+	return ""
+}
+
+//callerPackage is the import path of the caller's package
+//Caller.Package() includes the receiver type or "init" for some functions,
+//e.g. "github.com/me/server.init" for "github.com/me/server.init.0"
+func callerPackage(caller logger.Caller) string {
+	pkg := caller.Package()
+	slash := strings.LastIndex(pkg, "/")
+	if dot := strings.Index(pkg[slash+1:], "."); dot >= 0 {
+		pkg = pkg[:slash+1+dot]
+	}
+	return pkg
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//rule is one validation rule from a validate tag, e.g. "min=1"
+type rule struct {
+	name  string
+	param string
+	re    *regexp.Regexp //for regexp=...
+}
+
+var hostnameRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*\.?$`)
+
+//parseRules parses a validate tag into rules:
+//	min=1, max=65535   numbers, durations and sizes compare the value,
+//	                   strings, lists and maps compare the length
+//	oneof=http|https   value must be one of the options
+//	hostname           RFC 1123 host name
+//	url                absolute URL with scheme and host
+//	regexp=^[a-z]+$    string must match, must be the last rule as it may contain commas
+//	required_if=mode tls   field must be set when field mode (json name) is "tls"
+func parseRules(tag string) ([]rule, error) {
+	rules := []rule{}
+	for tag != "" {
+		var item string
+		if strings.HasPrefix(tag, "regexp=") {
+			item, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			item, tag = tag[:i], tag[i+1:]
+		} else {
+			item, tag = tag, ""
+		}
+		r := rule{name: item}
+		if i := strings.Index(item, "="); i >= 0 {
+			r.name, r.param = item[:i], item[i+1:]
+		}
+		switch r.name {
+		case "min", "max", "oneof", "required_if":
+			if r.param == "" {
+				return nil, fmt.Errorf("rule %s requires a parameter", r.name)
+			}
+		case "hostname", "url":
+		case "regexp":
+			re, err := regexp.Compile(r.param)
+			if err != nil {
+				return nil, fmt.Errorf("rule regexp: %v", err)
+			}
+			r.re = re
+		default:
+			return nil, fmt.Errorf("unknown rule \"%s\"", r.name)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+} //parseRules()
+
+//checkRules validates field f of struct value sv against its rules
+func checkRules(sv reflect.Value, f fieldInfo, fv reflect.Value) []error {
+	if f.rulesErr != nil {
+		return []error{fmt.Errorf("invalid validate tag: %v", f.rulesErr)}
+	}
+	errs := []error{}
+	for _, r := range f.rules {
+		if r.name == "required_if" {
+			if err := checkRequiredIf(sv, r, fv); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		v := fv
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				break
+			}
+			v = v.Elem()
+		}
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			continue //not set, use required_if or the required tag
+		}
+		if err := checkRule(r, v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+} //checkRules()
+
+func checkRule(r rule, v reflect.Value) error {
+	switch r.name {
+	case "min", "max":
+		return checkRange(r, v)
+
+	case "oneof":
+		s := fmt.Sprintf("%v", v.Interface())
+		for _, option := range strings.Split(r.param, "|") {
+			if s == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s is not one of %s", describe(v.Interface()), strings.ReplaceAll(r.param, "|", ", "))
+
+	case "hostname":
+		if v.Kind() != reflect.String || len(v.String()) > 253 || !hostnameRegex.MatchString(v.String()) {
+			return fmt.Errorf("%s is not a valid hostname", describe(v.Interface()))
+		}
+
+	case "url":
+		if v.Kind() != reflect.String {
+			if u, ok := v.Interface().(url.URL); ok && u.Scheme != "" && u.Host != "" {
+				return nil
+			}
+			return fmt.Errorf("%s is not a valid URL", describe(v.Interface()))
+		}
+		if u, err := url.Parse(v.String()); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%s is not a valid URL", describe(v.Interface()))
+		}
+
+	case "regexp":
+		if v.Kind() != reflect.String || !r.re.MatchString(v.String()) {
+			return fmt.Errorf("%s does not match %s", describe(v.Interface()), r.param)
+		}
+	}
+	return nil
+} //checkRule()
+
+//checkRange checks min/max against the value of numbers or the length of strings, lists and maps
+func checkRange(r rule, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		limit, err := strconv.Atoi(r.param)
+		if err != nil {
+			return fmt.Errorf("invalid %s=%s for length", r.name, r.param)
+		}
+		if r.name == "min" && v.Len() < limit {
+			return fmt.Errorf("length %d is less than %d", v.Len(), limit)
+		}
+		if r.name == "max" && v.Len() > limit {
+			return fmt.Errorf("length %d is more than %d", v.Len(), limit)
+		}
+		return nil
+	}
+
+	//parse the limit as the field type, so durations and sizes can use units
+	limitValue, err := convert(r.param, v.Type())
+	if err != nil {
+		return fmt.Errorf("invalid %s=%s for %v", r.name, r.param, v.Type())
+	}
+	limit := reflect.ValueOf(limitValue)
+	var cmp int
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		cmp = compare(v.Int(), limit.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		cmp = compare(v.Uint(), limit.Uint())
+	case reflect.Float32, reflect.Float64:
+		cmp = compare(v.Float(), limit.Float())
+	default:
+		return fmt.Errorf("%s does not apply to %v", r.name, v.Type())
+	}
+	if r.name == "min" && cmp < 0 {
+		return fmt.Errorf("%v is less than %s", v.Interface(), r.param)
+	}
+	if r.name == "max" && cmp > 0 {
+		return fmt.Errorf("%v is more than %s", v.Interface(), r.param)
+	}
+	return nil
+} //checkRange()
+
+func compare[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+//checkRequiredIf checks required_if=<field> <value>
+//the other field is named as in config (json name) and compared as a string
+func checkRequiredIf(sv reflect.Value, r rule, fv reflect.Value) error {
+	otherName, otherValue := r.param, ""
+	if i := strings.Index(r.param, " "); i >= 0 {
+		otherName, otherValue = r.param[:i], r.param[i+1:]
+	}
+	for _, other := range structFields(sv.Type()) {
+		if other.name != otherName {
+			continue
+		}
+		ov, ok := fieldValueIfSet(sv, other.index)
+		if !ok {
+			return nil
+		}
+		for ov.Kind() == reflect.Ptr && !ov.IsNil() {
+			ov = ov.Elem()
+		}
+		if fmt.Sprintf("%v", ov.Interface()) == otherValue && fv.IsZero() {
+			return fmt.Errorf("required when %s is %s", otherName, strconv.Quote(otherValue))
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid required_if: unknown field %s", otherName)
+} //checkRequiredIf()
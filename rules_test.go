@@ -0,0 +1,84 @@
+package config_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stewelarend/config"
+)
+
+type ruledServerConfig struct {
+	Scheme   string          `json:"scheme" validate:"oneof=http|https"`
+	Host     string          `json:"host" validate:"hostname"`
+	Port     int             `json:"port" validate:"min=1,max=65535"`
+	Timeout  time.Duration   `json:"timeout" validate:"min=1s,max=1m"`
+	MaxSize  config.ByteSize `json:"max_size" validate:"max=1MiB"`
+	Proxy    string          `json:"proxy" validate:"url"`
+	Name     string          `json:"name" validate:"min=3,regexp=^[a-z,]+$"`
+	Tags     []string        `json:"tags" validate:"max=2"`
+	TLS      bool            `json:"tls"`
+	CertFile string          `json:"cert_file" validate:"required_if=tls true"`
+}
+
+func TestValidateRules(t *testing.T) {
+	config.SetDefault("rules.valid", map[string]interface{}{
+		"scheme":    "https",
+		"host":      "api.example.com",
+		"port":      443,
+		"timeout":   "30s",
+		"max_size":  "512KiB",
+		"proxy":     "http://proxy:3128",
+		"name":      "a,b",
+		"tags":      []interface{}{"x"},
+		"tls":       true,
+		"cert_file": "cert.pem",
+	})
+	if _, err := config.Bind[ruledServerConfig]("rules.valid"); err != nil {
+		t.Fatalf("valid config failed: %v", err)
+	}
+
+	config.SetDefault("rules.invalid", map[string]interface{}{
+		"scheme":   "ftp",
+		"host":     "-bad-",
+		"port":     70000,
+		"timeout":  "2m",
+		"max_size": "2MiB",
+		"proxy":    "proxy:3128",
+		"name":     "AB",
+		"tags":     "x,y,z",
+		"tls":      true,
+	})
+	_, err := config.Bind[ruledServerConfig]("rules.invalid")
+	var errs config.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	expected := []string{
+		`rules.invalid.scheme: "ftp" is not one of http, https`,
+		`rules.invalid.host: "-bad-" is not a valid hostname`,
+		`rules.invalid.port: 70000 is more than 65535`,
+		`rules.invalid.timeout: 2m0s is more than 1m`,
+		`rules.invalid.max_size: 2MiB is more than 1MiB`,
+		`rules.invalid.proxy: "proxy:3128" is not a valid URL`,
+		`rules.invalid.name: length 2 is less than 3`,
+		`rules.invalid.name: "AB" does not match ^[a-z,]+$`,
+		`rules.invalid.tags: length 3 is more than 2`,
+		`rules.invalid.cert_file: required when tls is "true"`,
+	}
+	if err.Error() != strings.Join(expected, "; ") {
+		t.Fatalf("err=%v\nexpected %s", err, strings.Join(expected, "; "))
+	}
+}
+
+func TestValidateRuleTag(t *testing.T) {
+	type badTag struct {
+		Port int `json:"port" validate:"between=1"`
+	}
+	config.SetDefault("rules.badtag.port", 1)
+	_, err := config.Bind[badTag]("rules.badtag")
+	if err == nil || err.Error() != `rules.badtag.port: invalid validate tag: unknown rule "between"` {
+		t.Fatalf("wrong error: %v", err)
+	}
+}
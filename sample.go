@@ -0,0 +1,278 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+//sampleNode describes a config value for a sample file or documentation
+type sampleNode struct {
+	doc      string
+	env      string //name of the variable from the env tag
+	required bool
+	t        reflect.Type //of a struct field
+	rules    []rule
+	value    interface{} //default value of a leaf
+	hasValue bool
+	fields   map[string]*sampleNode
+	named    []string //names of the options of a named value, see RegisterNamed()
+}
+
+func (n *sampleNode) field(name string) *sampleNode {
+	if n.fields == nil {
+		n.fields = map[string]*sampleNode{}
+	}
+	if n.fields[name] == nil {
+		n.fields[name] = &sampleNode{}
+	}
+	return n.fields[name]
+}
+
+//WriteSample writes a sample config file with all defaults and the doc tags
+//of registered structs as comments, in "yaml" or "env" format
+//values without defaults and the options of named values are commented out
+//in env format, only the values with env tags are written, as only those are read from env
+func WriteSample(w io.Writer, format string) error {
+	root := sampleTree()
+	buf := bytes.NewBuffer(nil)
+	switch format {
+	case "yaml":
+		buf.WriteString("# sample config, generated from the defaults\n")
+		if err := writeYAMLSample(buf, "", root, false); err != nil {
+			return err
+		}
+	case "env":
+		buf.WriteString("# sample environment, generated from the defaults\n")
+		if err := writeEnvSample(buf, nil, root, false); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown sample format \"%s\" expecting yaml|env", format)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+} //WriteSample()
+
+//WriteSampleFile writes a sample to a file, e.g. "config.yaml" or ".env.example"
+//the format is "env" when the filename contains ".env", else "yaml"
+//it can be called from a program run by go generate, e.g.:
+//	//go:generate go run ./cmd/sample
+//	func main() {
+//		config.WriteSampleFile("config.yaml")
+//		config.WriteSampleFile(".env.example")
+//	}
+func WriteSampleFile(filename string) error {
+	format := "yaml"
+	if strings.Contains(filepath.Base(filename), ".env") {
+		format = "env"
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := WriteSample(buf, format); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+//sampleTree combines registered structs, named templates and defaults
+func sampleTree() *sampleNode {
+	root := &sampleNode{}
+	for _, name := range registeredNames() {
+		t, _ := registeredType(name)
+		if node := sampleNodeAt(root, name); node != nil {
+			addTypeSample(node, t, map[reflect.Type]bool{})
+		}
+	}
+	namedTypes := registeredNamed()
+	for _, name := range sortedKeys(namedTypes) {
+		node := sampleNodeAt(root, name)
+		if node == nil {
+			continue
+		}
+		node.named = sortedKeys(namedTypes[name])
+		for _, named := range node.named {
+			addTypeSample(node.field(named), namedTypes[name][named], map[reflect.Type]bool{})
+		}
+	}
+	addValueSample(root, defaults.Value())
+	return root
+}
+
+func sampleNodeAt(root *sampleNode, name string) *sampleNode {
+	p, err := ParsePath(name)
+	if err != nil {
+		return nil
+	}
+	node := root
+	for _, segment := range p {
+		node = node.field(segment)
+	}
+	return node
+}
+
+func addTypeSample(node *sampleNode, t reflect.Type, visiting map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr && !isScalar(t) && !hasDecoder(t) {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || isScalar(t) || hasDecoder(t) || visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	for _, f := range structFields(t) {
+		child := node.field(f.name)
+		child.doc = f.doc
+		child.env = f.env
+		child.t = f.t
+		child.rules = f.rules
+		child.required = f.required && !f.hasDefault
+		if f.hasDefault {
+			if defaultValue, err := convert(f.defaultValue, f.t); err == nil {
+				if v, err := structValue(reflect.ValueOf(defaultValue)); err == nil {
+					child.value, child.hasValue = v, true
+				}
+			}
+		}
+		addTypeSample(child, f.t, visiting)
+	}
+} //addTypeSample()
+
+func addValueSample(node *sampleNode, value interface{}) {
+	if obj, ok := value.(map[string]interface{}); ok && len(obj) > 0 {
+		for n, v := range obj {
+			addValueSample(node.field(n), v)
+		}
+		return
+	}
+	node.value, node.hasValue = value, true
+}
+
+func sampleDoc(node *sampleNode) string {
+	doc := node.doc
+	if node.required {
+		doc = strings.TrimSpace(doc + " (required)")
+	}
+	if len(node.named) > 0 {
+		doc = strings.TrimSpace(doc + " configure one of: " + strings.Join(node.named, ", "))
+	}
+	return doc
+}
+
+func writeYAMLSample(buf *bytes.Buffer, indent string, node *sampleNode, commented bool) error {
+	for _, name := range sortedKeys(node.fields) {
+		child := node.fields[name]
+		key, err := yamlScalar(name)
+		if err != nil {
+			return err
+		}
+		if doc := sampleDoc(child); doc != "" {
+			fmt.Fprintf(buf, "%s# %s\n", indent, doc)
+		}
+		prefix := indent
+		if commented {
+			prefix += "# "
+		}
+		switch {
+		case len(child.fields) > 0:
+			fmt.Fprintf(buf, "%s%s:\n", prefix, key)
+			//options of named values are commented out, uncomment one
+			if err := writeYAMLSample(buf, indent+"  ", child, commented || len(child.named) > 0); err != nil {
+				return err
+			}
+		case child.hasValue:
+			s, err := yamlScalar(child.value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "%s%s: %s\n", prefix, key, s)
+		default:
+			fmt.Fprintf(buf, "%s# %s:\n", indent, key)
+		}
+	}
+	return nil
+} //writeYAMLSample()
+
+func writeEnvSample(buf *bytes.Buffer, p Path, node *sampleNode, commented bool) error {
+	for _, name := range sortedKeys(node.fields) {
+		child := node.fields[name]
+		childPath := p.Add(name)
+		if len(child.fields) > 0 {
+			if len(child.named) > 0 && child.hasEnv() {
+				fmt.Fprintf(buf, "# %s: %s\n", childPath, sampleDoc(child))
+			}
+			if err := writeEnvSample(buf, childPath, child, commented || len(child.named) > 0); err != nil {
+				return err
+			}
+			continue
+		}
+		env := envNameOf(childPath, child)
+		if env == "" {
+			continue //not read from env
+		}
+		if doc := sampleDoc(child); doc != "" {
+			fmt.Fprintf(buf, "# %s\n", doc)
+		}
+		s := ""
+		if child.hasValue {
+			var err error
+			if s, err = envValue(child.value); err != nil {
+				return fmt.Errorf("%s: %v", childPath, err)
+			}
+		}
+		prefix := ""
+		if commented || !child.hasValue {
+			prefix = "#"
+		}
+		fmt.Fprintf(buf, "%s%s=%s\n", prefix, env, s)
+	}
+	return nil
+} //writeEnvSample()
+
+//envNames returns the variable of each value that is read from env, by name
+func envNames() map[string]string {
+	names := map[string]string{}
+	sampleTree().addEnvNames(nil, names)
+	return names
+}
+
+func (node *sampleNode) addEnvNames(p Path, names map[string]string) {
+	for name, child := range node.fields {
+		childPath := p.Add(name)
+		if env := envNameOf(childPath, child); env != "" {
+			names[childPath.String()] = env
+		}
+		child.addEnvNames(childPath, names)
+	}
+}
+
+var envVariableRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+//envNameOf is the variable that sets a value in env: the env tag of its struct field,
+//or the name of a top-level value that the env source reads, else ""
+func envNameOf(p Path, node *sampleNode) string {
+	if node.env != "" {
+		return node.env
+	}
+	if len(p) == 1 && len(node.fields) == 0 && envVariableRegex.MatchString(p[0]) {
+		return p[0]
+	}
+	return ""
+}
+
+//hasEnv is true when a value in the node is read from env
+func (node *sampleNode) hasEnv() bool {
+	if node.env != "" {
+		return true
+	}
+	for _, child := range node.fields {
+		if child.hasEnv() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stewelarend/config"
+)
+
+type sampleHTTPConfig struct {
+	Address string `json:"address" default:"localhost" doc:"Interface address"`
+	Port    int    `json:"port" default:"8000" env:"HTTP_PORT" doc:"TCP port to listen on"`
+	Name    string `json:"name" required:"true"`
+}
+
+type sampleZMQConfig struct {
+	Endpoint string `json:"endpoint" default:"tcp://*:5555"`
+}
+
+func TestWriteSample(t *testing.T) {
+	config.Register("sample.http", sampleHTTPConfig{})
+	config.RegisterNamed("sample.server", map[string]interface{}{"http": sampleHTTPConfig{}, "zmq": sampleZMQConfig{}})
+	config.SetDefault("sample.log.level", "info")
+	config.SetDefault("sample.log.outputs", []interface{}{"stdout", "file"})
+
+	tests := map[string]string{
+		"yaml": "" +
+			"sample:\n" +
+			"  http:\n" +
+			"    # Interface address\n" +
+			"    address: localhost\n" +
+			"    # (required)\n" +
+			"    # name:\n" +
+			"    # TCP port to listen on\n" +
+			"    port: 8000\n" +
+			"  log:\n" +
+			"    level: info\n" +
+			"    outputs: [\"stdout\",\"file\"]\n" +
+			"  # configure one of: http, zmq\n" +
+			"  server:\n" +
+			"    # http:\n" +
+			"      # Interface address\n" +
+			"      # address: localhost\n" +
+			"      # (required)\n" +
+			"      # name:\n" +
+			"      # TCP port to listen on\n" +
+			"      # port: 8000\n" +
+			"    # zmq:\n" +
+			"      # endpoint: tcp://*:5555\n",
+		"env": "" +
+			"# TCP port to listen on\n" +
+			"HTTP_PORT=8000\n" +
+			"# sample.server: configure one of: http, zmq\n" +
+			"# TCP port to listen on\n" +
+			"#HTTP_PORT=8000\n",
+	}
+	for format, expected := range tests {
+		buf := bytes.NewBuffer(nil)
+		if err := config.WriteSample(buf, format); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte(expected)) {
+			t.Errorf("%s sample:\n%s\nexpected to contain:\n%s", format, buf.String(), expected)
+		}
+	}
+
+	//only names that are read from env: env tags and top-level names
+	config.SetDefault("sampletop", 1)
+	buf := bytes.NewBuffer(nil)
+	if err := config.WriteSample(buf, "env"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("\nsampletop=1\n")) {
+		t.Errorf("env sample does not have top-level sampletop:\n%s", buf.String())
+	}
+	if bytes.Contains(buf.Bytes(), []byte("SAMPLE_")) {
+		t.Errorf("env sample has names that are not read from env:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//Schema is a JSON Schema document, encode it with encoding/json
+type Schema map[string]interface{}
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+//JSONSchema returns a JSON Schema (draft 2020-12) for the whole config tree
+//it describes:
+//	- values in the defaults, with their types and default values
+//	- registered structs (see Register(), SetDefaultStruct()), with doc tags as
+//	  descriptions and validate tags as constraints
+//	- named templates (see RegisterNamed(), GetNamedStruct()) as oneOf
+//values that are only configured in sources are allowed but not described
+func JSONSchema() Schema {
+	root := valueSchema(defaults.Value())
+	root["$schema"] = schemaDraft
+
+	for _, name := range registeredNames() {
+		t, _ := registeredType(name)
+		setSchema(root, name, typeSchema(t, map[reflect.Type]bool{}))
+	}
+
+	namedTypes := registeredNamed()
+	namedNames := make([]string, 0, len(namedTypes))
+	for name := range namedTypes {
+		namedNames = append(namedNames, name)
+	}
+	sort.Strings(namedNames)
+	for _, name := range namedNames {
+		options := []interface{}{}
+		for _, named := range sortedKeys(namedTypes[name]) {
+			options = append(options, Schema{
+				"type":                 "object",
+				"properties":           Schema{named: typeSchema(namedTypes[name][named], map[reflect.Type]bool{})},
+				"required":             []string{named},
+				"additionalProperties": false,
+			})
+		}
+		if field := discriminatorOf(name); field != "" {
+			//or the item selected with the discriminator field
+			for _, named := range sortedKeys(namedTypes[name]) {
+				option := typeSchema(namedTypes[name][named], map[reflect.Type]bool{})
+				properties := Schema{field: Schema{"const": named}}
+				if itemProperties, ok := option["properties"].(Schema); ok {
+					for n, v := range itemProperties {
+						properties[n] = v
+					}
+				}
+				option["properties"] = properties
+				required, _ := option["required"].([]string)
+				option["required"] = append([]string{field}, required...)
+				options = append(options, option)
+			}
+		}
+		setSchema(root, name, Schema{"oneOf": options})
+	}
+
+	//defaults set for registered structs
+	addDefaults(root, defaults.Value())
+	return root
+} //JSONSchema()
+
+//setSchema sets the schema of a dotted name in the root object schema
+func setSchema(root Schema, name string, schema Schema) {
+	p, err := ParsePath(name)
+	if err != nil {
+		return
+	}
+	obj := root
+	for i, segment := range p {
+		props, _ := obj["properties"].(Schema)
+		if props == nil {
+			props = Schema{}
+			obj["properties"] = props
+			obj["type"] = "object"
+		}
+		if i == len(p)-1 {
+			props[segment] = schema
+			return
+		}
+		next, _ := props[segment].(Schema)
+		if next == nil || next["properties"] == nil && next["type"] != nil && next["type"] != "object" {
+			next = Schema{"type": "object"}
+			props[segment] = next
+		}
+		obj = next
+	}
+} //setSchema()
+
+//addDefaults sets default values from the config tree in the schema properties
+//they replace default tags, because they are the values that apply
+func addDefaults(schema Schema, value interface{}) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	props, _ := schema["properties"].(Schema)
+	for n, v := range obj {
+		propSchema, ok := props[n].(Schema)
+		if !ok {
+			continue
+		}
+		if _, isObj := v.(map[string]interface{}); isObj && propSchema["properties"] != nil {
+			addDefaults(propSchema, v)
+			continue
+		}
+		propSchema["default"] = v
+	}
+}
+
+//valueSchema describes a value from the defaults tree
+func valueSchema(value interface{}) Schema {
+	switch v := value.(type) {
+	case nil:
+		return Schema{}
+	case map[string]interface{}:
+		props := Schema{}
+		for n, item := range v {
+			props[n] = valueSchema(item)
+		}
+		return Schema{"type": "object", "properties": props}
+	case []interface{}:
+		schema := Schema{"type": "array", "default": v}
+		if len(v) > 0 {
+			if items := valueSchema(v[0]); items["type"] != nil {
+				schema["items"] = Schema{"type": items["type"]}
+			}
+		}
+		return schema
+	}
+	schema := typeSchema(reflect.TypeOf(value), map[reflect.Type]bool{})
+	schema["default"] = value
+	return schema
+} //valueSchema()
+
+//typeSchema describes a Go type as decoded by the config
+//visiting guards against recursive types
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) Schema {
+	for t.Kind() == reflect.Ptr && !isScalar(t) && !hasDecoder(t) {
+		t = t.Elem()
+	}
+	switch t {
+	case durationType:
+		return Schema{"type": []string{"string", "integer"}, "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`}
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case urlType:
+		return Schema{"type": "string", "format": "uri"}
+	case ipType, ipNetType:
+		return Schema{"type": "string"}
+	}
+	if hasDecoder(t) {
+		return Schema{} //decodes itself from any value
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return Schema{"type": []string{"string", "integer"}} //e.g. ByteSize
+		}
+		return Schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string"}
+		}
+		return Schema{"type": "array", "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": typeSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	}
+	return Schema{}
+} //typeSchema()
+
+//structSchema describes a struct with its field tags
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) Schema {
+	if visiting[t] {
+		return Schema{"type": "object"}
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	props := Schema{}
+	required := []string{}
+	conditions := []interface{}{}
+	fields := structFields(t)
+	for _, f := range fields {
+		fieldSchema := typeSchema(f.t, visiting)
+		if f.doc != "" {
+			fieldSchema["description"] = f.doc
+		}
+		if f.hasDefault {
+			if defaultValue, err := convert(f.defaultValue, f.t); err == nil {
+				if v, err := structValue(reflect.ValueOf(defaultValue)); err == nil {
+					fieldSchema["default"] = v
+				}
+			}
+		} else if f.required {
+			required = append(required, f.name)
+		}
+		for _, r := range f.rules {
+			if r.name == "required_if" {
+				if condition, ok := requiredIfSchema(fields, f, r); ok {
+					conditions = append(conditions, condition)
+				}
+				continue
+			}
+			ruleSchema(fieldSchema, f.t, r)
+		}
+		props[f.name] = fieldSchema
+	}
+
+	schema := Schema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if len(conditions) > 0 {
+		schema["allOf"] = conditions
+	}
+	if strictMode() {
+		schema["additionalProperties"] = false
+	}
+	return schema
+} //structSchema()
+
+//ruleSchema adds the constraints of a validate rule to the field schema
+func ruleSchema(schema Schema, t reflect.Type, r rule) {
+	for t.Kind() == reflect.Ptr && !isScalar(t) {
+		t = t.Elem()
+	}
+	switch r.name {
+	case "min", "max":
+		switch t.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			keyword := map[reflect.Kind]string{reflect.String: "Length", reflect.Slice: "Items", reflect.Array: "Items", reflect.Map: "Properties"}[t.Kind()]
+			if limit, err := strconv.Atoi(r.param); err == nil {
+				schema[r.name+keyword] = limit
+			}
+			return
+		}
+		if t == durationType || hasDecoder(t) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+			return //limits with units cannot be expressed in the schema
+		}
+		if limit, err := convert(r.param, t); err == nil {
+			schema[map[string]string{"min": "minimum", "max": "maximum"}[r.name]] = limit
+		}
+	case "oneof":
+		options := []interface{}{}
+		for _, option := range strings.Split(r.param, "|") {
+			value, err := convert(option, t)
+			if err != nil {
+				return
+			}
+			v, err := structValue(reflect.ValueOf(value))
+			if err != nil {
+				return
+			}
+			options = append(options, v)
+		}
+		schema["enum"] = options
+	case "hostname":
+		schema["format"] = "hostname"
+	case "url":
+		schema["format"] = "uri"
+	case "regexp":
+		schema["pattern"] = r.param
+	}
+} //ruleSchema()
+
+//requiredIfSchema expresses required_if=<field> <value> as if/then
+func requiredIfSchema(fields []fieldInfo, f fieldInfo, r rule) (Schema, bool) {
+	otherName, otherValue := r.param, ""
+	if i := strings.Index(r.param, " "); i >= 0 {
+		otherName, otherValue = r.param[:i], r.param[i+1:]
+	}
+	for _, other := range fields {
+		if other.name != otherName {
+			continue
+		}
+		value, err := convert(otherValue, other.t)
+		if err != nil {
+			return nil, false
+		}
+		constValue, err := structValue(reflect.ValueOf(value))
+		if err != nil {
+			return nil, false
+		}
+		return Schema{
+			"if":   Schema{"properties": Schema{otherName: Schema{"const": constValue}}, "required": []string{otherName}},
+			"then": Schema{"required": []string{f.name}},
+		}, true
+	}
+	return nil, false
+} //requiredIfSchema()
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,169 @@
+package config_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/configfile"
+)
+
+type schemaHTTPConfig struct {
+	Address string `json:"address" default:"localhost" doc:"Interface address" validate:"hostname"`
+	Port    int    `json:"port" default:"8000" validate:"min=1,max=65535"`
+	Scheme  string `json:"scheme" validate:"oneof=http|https"`
+	Name    string `json:"name" required:"true"`
+	TLS     bool   `json:"tls"`
+	Cert    string `json:"cert" validate:"required_if=tls true"`
+}
+
+type schemaZMQConfig struct {
+	Endpoints []string `json:"endpoints" validate:"min=1"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	config.SetDefault("schema.log.level", "info")
+	if err := config.Register("schema.http", schemaHTTPConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	config.SetDefault("schema.http.port", 9000)
+	if err := config.RegisterNamed("schema.server", map[string]interface{}{"http": schemaHTTPConfig{}, "zmq": &schemaZMQConfig{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := config.JSONSchema()
+	if s["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+		t.Fatalf("wrong $schema %v", s["$schema"])
+	}
+	schemaJSON, err := json.Marshal(s["properties"].(config.Schema)["schema"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"properties":{` +
+		`"http":{"allOf":[{"if":{"properties":{"tls":{"const":true}},"required":["tls"]},"then":{"required":["cert"]}}],` +
+		`"properties":{` +
+		`"address":{"default":"localhost","description":"Interface address","format":"hostname","type":"string"},` +
+		`"cert":{"type":"string"},` +
+		`"name":{"type":"string"},` +
+		`"port":{"default":9000,"maximum":65535,"minimum":1,"type":"integer"},` +
+		`"scheme":{"enum":["http","https"],"type":"string"},` +
+		`"tls":{"type":"boolean"}},` +
+		`"required":["name"],"type":"object"},` +
+		`"log":{"properties":{"level":{"default":"info","type":"string"}},"type":"object"},` +
+		`"server":{"oneOf":[` +
+		`{"additionalProperties":false,"properties":{"http":{"allOf":[{"if":{"properties":{"tls":{"const":true}},"required":["tls"]},"then":{"required":["cert"]}}],"properties":{"address":{"default":"localhost","description":"Interface address","format":"hostname","type":"string"},"cert":{"type":"string"},"name":{"type":"string"},"port":{"default":8000,"maximum":65535,"minimum":1,"type":"integer"},"scheme":{"enum":["http","https"],"type":"string"},"tls":{"type":"boolean"}},"required":["name"],"type":"object"}},"required":["http"],"type":"object"},` +
+		`{"additionalProperties":false,"properties":{"zmq":{"properties":{"endpoints":{"items":{"type":"string"},"minItems":1,"type":"array"}},"type":"object"}},"required":["zmq"],"type":"object"}` +
+		`]}},"type":"object"}`
+	if string(schemaJSON) != expected {
+		t.Fatalf("schema:\n%s\nexpected:\n%s", schemaJSON, expected)
+	}
+}
+
+func TestAddWithSchema(t *testing.T) {
+	t.Cleanup(func() { configfile.SetSchema(nil) })
+	for _, name := range []string{"checked.http", `checked."api.example.com"`, "layered.http"} {
+		name := name
+		t.Cleanup(func() { config.Unregister(name) })
+	}
+	if err := config.Register("checked.http", schemaHTTPConfig{}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	s := config.JSONSchema()
+
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("{\"checked\": {\n  \"http\": {\n    \"port\": 70000,\n    \"scheme\": \"ftp\"\n  }\n}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := configfile.AddWithSchema(bad, s)
+	var schemaErr *configfile.SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected SchemaError, got %v", err)
+	}
+	expected := []string{
+		bad + ":3:5: checked.http.port: 70000 is more than 65535",
+		bad + ":4:5: checked.http.scheme: \"ftp\" is not one of [http https]",
+	}
+	violations := []string{}
+	for _, v := range schemaErr.Violations {
+		violations = append(violations, v.String(bad))
+	}
+	if strings.Join(violations, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("violations:\n%s\nexpected:\n%s", strings.Join(violations, "\n"), strings.Join(expected, "\n"))
+	}
+	if _, ok := config.Get("checked.http.port"); ok {
+		t.Fatalf("rejected file was added")
+	}
+
+	//yaml violations have locations
+	badYAML := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(badYAML, []byte("checked:\n  http:\n    name: web\n    port: 70000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := configfile.AddWithSchema(badYAML, s); !errors.As(err, &schemaErr) || len(schemaErr.Violations) != 1 || schemaErr.Violations[0].String(badYAML) != badYAML+":4:5: checked.http.port: 70000 is more than 65535" {
+		t.Fatalf("wrong yaml violation: %v", err)
+	}
+
+	//quoted names are located by their segments
+	if err := config.Register(`checked."api.example.com"`, schemaHTTPConfig{}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	quoted := filepath.Join(dir, "quoted.json")
+	if err := os.WriteFile(quoted, []byte("{\"checked\": {\n  \"api.example.com\": {\"extra\": {\"x\": 1},\n    \"port\": 70000}}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := configfile.AddWithSchema(quoted, config.JSONSchema()); !errors.As(err, &schemaErr) || len(schemaErr.Violations) != 1 || schemaErr.Violations[0].String(quoted) != quoted+`:3:5: checked."api.example.com".port: 70000 is more than 65535` {
+		t.Fatalf("wrong quoted violation: %v", err)
+	}
+
+	//yaml objects are checked and can be retrieved with nested names
+	good := filepath.Join(dir, "good.yaml")
+	if err := os.WriteFile(good, []byte("checked:\n  http:\n    name: web\n    port: 8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configfile.SetSchema(s)
+	if err := configfile.Add(good); err != nil {
+		t.Fatalf("failed to add good file: %v", err)
+	}
+	if port, ok := config.GetInt("checked.http.port"); !ok || port != 8080 {
+		t.Fatalf("port=%v,%v", port, ok)
+	}
+
+	//a layer does not need required values, they are checked in the resolved config
+	if err := config.Register("layered.http", schemaHTTPConfig{}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	configfile.SetSchema(config.JSONSchema())
+	layer := filepath.Join(dir, "layer.json")
+	if err := os.WriteFile(layer, []byte(`{"layered":{"http":{"port":8081}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := configfile.Add(layer); err != nil {
+		t.Fatalf("failed to add layer: %v", err)
+	}
+	var errs config.ValidationErrors
+	if err := configfile.Check(); !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	found := false
+	for _, e := range errs {
+		if e.Error() == "layered.http.name: is required" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("missing required name in %v", errs)
+	}
+
+	//text values are checked as numbers, e.g. from the environment
+	if errs := config.JSONSchema().Check(map[string]interface{}{"layered": map[string]interface{}{"http": map[string]interface{}{"port": "8082"}}}, config.CheckPartial(), config.CheckText()); len(errs) > 0 {
+		t.Fatalf("text port failed: %v", errs)
+	}
+	if errs := config.JSONSchema().Check(map[string]interface{}{"layered": map[string]interface{}{"http": map[string]interface{}{"port": "x"}}}, config.CheckPartial(), config.CheckText()); len(errs) != 1 {
+		t.Fatalf("text port x passed: %v", errs)
+	}
+}
@@ -0,0 +1,380 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Check validates a config value tree against the schema, e.g. a file loaded by a source
+//it supports the keywords that JSONSchema() generates: type, properties, required,
+//additionalProperties, items, enum, const, minimum, maximum, minLength, maxLength,
+//minItems, maxItems, minProperties, maxProperties, pattern, format (date-time, uri,
+//hostname), allOf, anyOf, oneOf, if/then/else and not
+//unsupported keywords, e.g. $ref, are ignored
+//the errors have the paths of the values in the tree
+func (s Schema) Check(value interface{}, opts ...CheckOption) ValidationErrors {
+	mode := checkMode{}
+	for _, opt := range opts {
+		opt(&mode)
+	}
+	return checkSchema("", s, value, mode)
+}
+
+//CheckOption changes how Schema.Check() checks a value
+type CheckOption func(*checkMode)
+
+//CheckPartial checks one layer of the config, e.g. an override file,
+//without failing for required values that another layer can provide
+//required values are still used to select options of named values (oneOf)
+func CheckPartial() CheckOption {
+	return func(m *checkMode) {
+		m.partial = true
+	}
+}
+
+//CheckText accepts text for numbers and booleans, e.g. "8000" for an integer,
+//for sources that only have text values, e.g. environment variables
+func CheckText() CheckOption {
+	return func(m *checkMode) {
+		m.text = true
+	}
+}
+
+//checkMode holds the options of a check
+type checkMode struct {
+	partial   bool //skip required
+	text      bool //parse text scalars
+	selecting bool //check required of this level in a partial check, see checkSchema()
+}
+
+//asSchema accepts a Schema or a schema decoded from JSON
+func asSchema(v interface{}) (Schema, bool) {
+	switch s := v.(type) {
+	case Schema:
+		return s, true
+	case map[string]interface{}:
+		return Schema(s), true
+	}
+	return nil, false
+}
+
+func checkSchema(path string, s Schema, value interface{}, mode checkMode) ValidationErrors {
+	value = normalize(value)
+	checkRequired := !mode.partial || mode.selecting
+	mode.selecting = false //only for this level
+	errs := ValidationErrors{}
+	fail := func(p string, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Path: p, Err: fmt.Errorf(format, args...)})
+	}
+
+	if t, ok := s["type"]; ok {
+		types := schemaStrings(t)
+		if text, ok := value.(string); ok && mode.text {
+			value = parseText(types, text)
+		}
+		if !matchesType(types, value) {
+			fail(path, "expected %s, got %s", strings.Join(types, " or "), describe(value))
+			return errs //other keywords do not apply to the wrong type
+		}
+	}
+	if enum, ok := s["enum"]; ok {
+		found := false
+		for _, option := range schemaList(enum) {
+			if schemaEqual(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail(path, "%s is not one of %v", describe(value), enum)
+		}
+	}
+	if c, ok := s["const"]; ok && !schemaEqual(c, value) {
+		fail(path, "%s is not %v", describe(value), c)
+	}
+
+	if f, ok := toFloat(value); ok {
+		if limit, ok := toFloat(s["minimum"]); ok && f < limit {
+			fail(path, "%v is less than %v", value, s["minimum"])
+		}
+		if limit, ok := toFloat(s["maximum"]); ok && f > limit {
+			fail(path, "%v is more than %v", value, s["maximum"])
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		checkLength(path, s, "Length", len([]rune(v)), fail)
+		if pattern, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				fail(path, "%s does not match %s", describe(v), pattern)
+			}
+		}
+		if format, ok := s["format"].(string); ok && !matchesFormat(format, v) {
+			fail(path, "%s is not a valid %s", describe(v), format)
+		}
+
+	case []interface{}:
+		checkLength(path, s, "Items", len(v), fail)
+		if items, ok := asSchema(s["items"]); ok {
+			for i, item := range v {
+				errs = append(errs, checkSchema(joinKey(path, fmt.Sprintf("[%d]", i)), items, item, mode)...)
+			}
+		}
+
+	case map[string]interface{}:
+		checkLength(path, s, "Properties", len(v), fail)
+		if checkRequired {
+			for _, n := range schemaStrings(s["required"]) {
+				if _, ok := v[n]; !ok {
+					fail(joinKey(path, quoteSegment(n)), "is required")
+				}
+			}
+		}
+		props, _ := asSchema(s["properties"])
+		for _, n := range sortedKeys(v) {
+			itemPath := joinKey(path, quoteSegment(n))
+			if propSchema, ok := asSchema(props[n]); ok {
+				errs = append(errs, checkSchema(itemPath, propSchema, v[n], mode)...)
+				continue
+			}
+			switch additional := s["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					fail(itemPath, "is not allowed")
+				}
+			default:
+				if additionalSchema, ok := asSchema(additional); ok {
+					errs = append(errs, checkSchema(itemPath, additionalSchema, v[n], mode)...)
+				}
+			}
+		}
+	}
+
+	for _, sub := range schemaList(s["allOf"]) {
+		if subSchema, ok := asSchema(sub); ok {
+			errs = append(errs, checkSchema(path, subSchema, value, mode)...)
+		}
+	}
+	//required selects options and conditions, also in a partial check
+	selecting := mode
+	selecting.selecting = true
+	if anyOf, ok := s["anyOf"]; ok {
+		if matched, best := checkOptions(path, schemaList(anyOf), value, selecting); matched == 0 {
+			errs = append(errs, best...)
+		}
+	}
+	if oneOf, ok := s["oneOf"]; ok {
+		matched, best := checkOptions(path, schemaList(oneOf), value, selecting)
+		switch {
+		case matched == 0:
+			errs = append(errs, best...)
+		case matched > 1:
+			fail(path, "matches %d options, expecting one", matched)
+		}
+	}
+	if ifSchema, ok := asSchema(s["if"]); ok {
+		branch := "else"
+		if len(checkSchema(path, ifSchema, value, selecting)) == 0 {
+			branch = "then"
+		}
+		if branchSchema, ok := asSchema(s[branch]); ok {
+			errs = append(errs, checkSchema(path, branchSchema, value, mode)...)
+		}
+	}
+	if notSchema, ok := asSchema(s["not"]); ok && len(checkSchema(path, notSchema, value, selecting)) == 0 {
+		fail(path, "must not match %v", s["not"])
+	}
+	return errs
+} //checkSchema()
+
+//checkOptions counts the options that match
+//when none match, the errors of the closest option are returned,
+//e.g. for named config, the template that has the configured name
+func checkOptions(path string, options []interface{}, value interface{}, mode checkMode) (int, ValidationErrors) {
+	matched := 0
+	var best ValidationErrors
+	for _, option := range options {
+		optionSchema, ok := asSchema(option)
+		if !ok {
+			continue
+		}
+		errs := checkSchema(path, optionSchema, value, mode)
+		if len(errs) == 0 {
+			matched++
+			continue
+		}
+		if best == nil || len(errs) < len(best) {
+			best = errs
+		}
+	}
+	return matched, best
+}
+
+func checkLength(path string, s Schema, keyword string, n int, fail func(string, string, ...interface{})) {
+	if limit, ok := toFloat(s["min"+keyword]); ok && float64(n) < limit {
+		fail(path, "%s %d is less than %v", strings.ToLower(keyword), n, s["min"+keyword])
+	}
+	if limit, ok := toFloat(s["max"+keyword]); ok && float64(n) > limit {
+		fail(path, "%s %d is more than %v", strings.ToLower(keyword), n, s["max"+keyword])
+	}
+}
+
+func matchesType(types []string, value interface{}) bool {
+	for _, t := range types {
+		switch t {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := toFloat(value); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := toFloat(value); ok && f == float64(int64(f)) {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+	return false
+} //matchesType()
+
+//parseText converts text to the first of the types it parses as,
+//e.g. "8000" to an integer, or returns the text
+func parseText(types []string, text string) interface{} {
+	for _, t := range types {
+		switch t {
+		case "integer":
+			if i, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err == nil {
+				return i
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+				return f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(strings.TrimSpace(text)); err == nil {
+				return b
+			}
+		case "string":
+			return text
+		}
+	}
+	return text
+}
+
+func matchesFormat(format string, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	case "hostname":
+		return len(s) <= 253 && hostnameRegex.MatchString(s)
+	}
+	return true //unknown formats are not checked
+}
+
+//normalize converts values from decoders to the types used in the schema checks,
+//e.g. map[interface{}]interface{} from YAML to map[string]interface{}
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}, string, bool, nil:
+		return v
+	case *values:
+		return v.Value()
+	case map[interface{}]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			obj[fmt.Sprintf("%v", k)] = item
+		}
+		return obj
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = rv.Index(i).Interface()
+		}
+		return list
+	case reflect.Map:
+		obj := map[string]interface{}{}
+		iter := rv.MapRange()
+		for iter.Next() {
+			obj[fmt.Sprintf("%v", iter.Key())] = iter.Value().Interface()
+		}
+		return obj
+	}
+	return value
+} //normalize()
+
+func toFloat(value interface{}) (float64, bool) {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+//schemaEqual compares values from the schema and config, numbers by value
+func schemaEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+//schemaStrings accepts a string or list of strings, e.g. for "type" and "required"
+func schemaStrings(v interface{}) []string {
+	if s, ok := v.(string); ok {
+		return []string{s}
+	}
+	list := []string{}
+	for _, item := range schemaList(v) {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func schemaList(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+	if list, ok := normalize(v).([]interface{}); ok {
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"sync"
+)
+
+//lookups de-duplicates concurrent resolution of the same name
+var lookups = &flightGroup{calls: map[string]*flight{}}
+
+//flightGroup runs one call per key at a time,
+//callers that ask for a key that is already in flight wait for its result
+type flightGroup struct {
+	mutex sync.Mutex
+	calls map[string]*flight
+}
+
+//flight is a call in progress
+type flight struct {
+	done  chan struct{} //closed when value and err are set
+	value interface{}
+	err   error
+}
+
+//do calls fn for key, unless another call for key is in flight, then it
+//waits for that result instead
+//fn runs in its own goroutine and must not depend on any caller's context:
+//ctx only limits the wait of this caller, without affecting the call
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	g.mutex.Lock()
+	f, ok := g.calls[key]
+	if !ok {
+		f = &flight{done: make(chan struct{})}
+		g.calls[key] = f
+		go func() {
+			defer func() {
+				g.mutex.Lock()
+				delete(g.calls, key)
+				g.mutex.Unlock()
+				close(f.done)
+			}()
+			f.value, f.err = fn()
+		}()
+	}
+	g.mutex.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
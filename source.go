@@ -1,12 +1,13 @@
 package config
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/stewelarend/logger"
 )
@@ -17,13 +18,6 @@ type ISource interface {
 	//get a key-value pair from this source
 	//the name may use dotted notation for nesting
 	Get(name string) (value interface{}, ok bool)
-
-	//get a named item and its value
-	//e.g. with config {"rpc":{"server":{"http":{"port":8000}}}}
-	//     GetNamed("rpc.server") will return:
-	//			named="http"
-	//			value={"port":8000}
-	//GetNamed(name string) (named string, value interface{}, ok bool)
 }
 
 type ISourceConstructor interface {
@@ -34,21 +28,84 @@ func RegisterSource(name string, constructor ISourceConstructor) {
 	sourceConstructors[name] = constructor
 }
 
-func AddSource(s ISource) {
+//SourceOption configures a source in AddSource()
+type SourceOption func(*sourceEntry)
+
+//WithSourceTimeout limits the time of each Get() on the source
+//when it takes longer, Lookup() fails with a *SourceError
+//that wraps context.DeadlineExceeded
+func WithSourceTimeout(timeout time.Duration) SourceOption {
+	return func(e *sourceEntry) {
+		e.timeout = timeout
+	}
+}
+
+//AddSource adds a source after those already added
+//it fails with ErrFrozen after Freeze()
+func AddSource(s ISource, opts ...SourceOption) error {
+	if Frozen() {
+		return fmt.Errorf("cannot add source %s: %w", sourceName(s), ErrFrozen)
+	}
 	if s != nil {
+		entry := sourceEntry{source: s}
+		for _, opt := range opts {
+			opt(&entry)
+		}
 		sourcesMutex.Lock()
 		defer sourcesMutex.Unlock()
-		sources = append(sources, s)
+		sources = append(sources, entry)
 	}
+	return nil
+}
+
+//sourceEntry is an added source with its options
+type sourceEntry struct {
+	source  ISource
+	timeout time.Duration //0 for no limit
 }
 
 var (
 	sourcesMutex       sync.Mutex
 	sourceConstructors = map[string]ISourceConstructor{}
-	sources            = []ISource{}
+	sources            = []sourceEntry{}
 	defined            = NewValues("defined", nil)
+	definedSources     = map[string]string{} //name of the source that defined each value
 )
 
+//sourceName is the name of a source used in errors and reports
+//sources can name themselves by implementing fmt.Stringer
+func sourceName(s ISource) string {
+	if stringer, ok := s.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", s)
+}
+
+//sourceList returns a copy of the sources, so that they can be called
+//without holding sourcesMutex
+func sourceList() []sourceEntry {
+	sourcesMutex.Lock()
+	defer sourcesMutex.Unlock()
+	return append([]sourceEntry{}, sources...)
+}
+
+//sourceOf returns the name of the source that defined the value
+//e.g. if "server" was defined from a file, "server.http.port" also came from that file
+func sourceOf(name string) string {
+	p, err := ParsePath(name)
+	if err != nil {
+		return ""
+	}
+	sourcesMutex.Lock()
+	defer sourcesMutex.Unlock()
+	for i := len(p); i > 0; i-- {
+		if source, ok := definedSources[p[:i].String()]; ok {
+			return source
+		}
+	}
+	return ""
+}
+
 //GetValue() is same as Get() but only returns the value if defined else nil
 func GetValue(name string) interface{} {
 	if v, ok := Get(name); ok {
@@ -57,87 +114,282 @@ func GetValue(name string) interface{} {
 	return nil
 }
 
-func GetInt(name string) (int, bool) {
-	v, ok := Get(name)
-	if !ok {
-		return 0, false
-	}
-	if i, ok := v.(int); ok {
-		return i, true
+//sourceWith returns the name of the first source that has a value for name
+//this is where the value came from, or would have come from if it was used
+func sourceWith(name string) string {
+	for _, e := range sourceList() {
+		if _, ok, err := e.get(context.Background(), name); err == nil && ok {
+			return sourceName(e.source)
+		}
 	}
-	i64, err := strconv.ParseInt(fmt.Sprintf("%v", v), 10, 64)
+	return ""
+}
+
+//Get a config value
+//returns ok=false when not defined or not valid, use Lookup() to get the reason
+func Get(name string) (interface{}, bool) {
+	v, err := Lookup(name)
 	if err != nil {
-		return 0, false
+		if !errors.Is(err, ErrNotDefined) {
+			log.Errorf("%v", err)
+		}
+		return nil, false
 	}
-	return int(i64), true
+	return v, true
+} //Get()
+
+//Lookup a config value
+//the error can be tested with errors.Is() for ErrNotDefined or ErrInvalidName
+//and with errors.As() for *SourceError when a source failed
+func Lookup(name string) (interface{}, error) {
+	return LookupContext(context.Background(), name)
 }
 
-func GetString(name string) (string, bool) {
-	v, ok := Get(name)
-	if !ok {
-		return "", false
+//LookupContext is Lookup() with a context to cancel or limit the time
+//the caller waits for the sources, which are limited by the timeouts set
+//with WithSourceTimeout()
+//
+//sources are called without holding a global lock, so a slow source only
+//delays the lookups that need it, and concurrent lookups of the same name
+//share one call to the sources, which continues when one caller stops waiting
+func LookupContext(ctx context.Context, name string) (interface{}, error) {
+	return lookup(ctx, name, false)
+} //LookupContext()
+
+//lookup a value, with named=true sources that implement INamedSource
+//are asked for the named item instead of the value
+func lookup(ctx context.Context, name string, named bool) (interface{}, error) {
+	//after Freeze(), read the snapshot without locking
+	if s := frozenSnapshot(); s != nil {
+		return s.lookup(name)
 	}
-	if s, ok := v.(string); ok {
-		return s, true
+	log.Debugf("Lookup(%s)...", name)
+	p, err := ParsePath(name)
+	if err != nil {
+		return nil, err
 	}
-	return fmt.Sprintf("%v", v), true
-}
 
-func Get(name string) (interface{}, bool) {
-	log.Debugf("Get(%s)...", name)
 	//if already defined, use that value
 	if v, err := defined.GetAndLock(name); err == nil {
-		return v, true
+		return v, nil
 	}
 
-	//not yet defined, try to retrieve from sources
-	sourcesMutex.Lock()
-	defer sourcesMutex.Unlock()
-	for _, s := range sources {
-		if v, ok := s.Get(name); ok {
-			//found in this source
-			//if this is an object and we also have defaults
-			//for the object, then need to merge
-			//e.g. if defaults has server:{address:"localhost", port:8000}
-			//      and source has server:{port:9000}
-			//      then we define server:{address:"localhost", port:9000}
-			if sourceObj, ok := v.(map[string]interface{}); ok {
-				if defaultValue, ok := defaults.Get(name); ok {
-					if defaultObj, ok := defaultValue.(map[string]interface{}); ok {
-						//has source and default obj
-						//start with default and add source values into it
-						v = mergedObj(defaultObj, sourceObj)
-					}
+	//not yet defined, resolve once for all concurrent callers
+	key := p.String()
+	if named {
+		key = "named " + key
+	}
+	//the sources are called with their own timeouts only, as the result is
+	//shared, and ctx only limits how long this caller waits for it
+	return lookups.do(ctx, key, func() (interface{}, error) {
+		return resolveValue(context.Background(), name, named)
+	})
+} //lookup()
+
+//resolveValue retrieves a value from the sources or else the defaults
+//and defines it
+func resolveValue(ctx context.Context, name string, named bool) (interface{}, error) {
+	for _, e := range sourceList() {
+		if named && namedSourceOf(e.source) != nil {
+			item, v, ok, err := e.getNamed(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			//define only the named item, so defaults of other items are not added
+			if _, err := defineSourceValue(name+"."+quoteSegment(item), v, sourceName(e.source)); err != nil {
+				return nil, err
+			}
+			return defined.GetAndLock(name)
+		}
+		v, ok, err := e.get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		//found in this source
+		if obj, isObj := v.(map[string]interface{}); isObj && named && !hasDiscriminator(name, obj) {
+			//define each configured item, so defaults of other items are not added
+			for _, item := range sortedKeys(obj) {
+				if _, err := defineSourceValue(name+"."+quoteSegment(item), obj[item], sourceName(e.source)); err != nil {
+					return nil, err
 				}
 			}
-
-			//copy to defined and lock
-			if err := defined.Set(name, v); err != nil {
-				panic(fmt.Errorf("failed to define config: %v", err))
+			if len(obj) > 0 {
+				return defined.GetAndLock(name)
 			}
-			v, _ = defined.GetAndLock(name)
-			return v, true
 		}
+		return defineSourceValue(name, v, sourceName(e.source))
 	}
 
 	//still not defined, try to retrieve from defaults
 	if v, ok := defaults.Get(name); ok {
-		if err := defined.Set(name, v); err != nil {
-			panic(fmt.Errorf("failed to apply default value: %v", err))
-		}
-		v, _ := defined.GetAndLock(name)
-		return v, true
+		return define(name, v, "defaults")
 	}
 
 	//config is undefined
-	return nil, false
-} //Get()
+	return nil, fmt.Errorf("%s %w", name, ErrNotDefined)
+} //resolveValue()
+
+//defineSourceValue defines a value found in a source
+func defineSourceValue(name string, v interface{}, source string) (interface{}, error) {
+	//if this is an object and we also have defaults
+	//for the object, then need to merge
+	//e.g. if defaults has server:{address:"localhost", port:8000}
+	//      and source has server:{port:9000}
+	//      then we define server:{address:"localhost", port:9000}
+	if sourceObj, ok := v.(map[string]interface{}); ok {
+		if defaultValue, ok := defaults.Get(name); ok {
+			if defaultObj, ok := defaultValue.(map[string]interface{}); ok {
+				//has source and default obj
+				//start with default and add source values into it
+				v = mergedObj(defaultObj, sourceObj)
+			}
+		}
+	}
+	return define(name, v, source)
+}
+
+//define copies a value to defined and locks it
+//if it was defined meanwhile, e.g. by a lookup of its parent, that value is used
+func define(name string, v interface{}, source string) (interface{}, error) {
+	p, err := ParsePath(name)
+	if err != nil {
+		return nil, err
+	}
+	sourcesMutex.Lock()
+	defer sourcesMutex.Unlock()
+	if existing, err := defined.GetAndLock(name); err == nil {
+		return existing, nil
+	}
+	if defined.lockedParent(p) {
+		//the object that contains it was already defined, e.g. from
+		//an earlier source that did not have this value
+		return nil, fmt.Errorf("%s %w (shadowed by %s)", name, ErrNotDefined, p[:len(p)-1])
+	}
+	if err := defined.Set(name, v); err != nil {
+		return nil, fmt.Errorf("cannot define %s from %s: %v", name, source, err)
+	}
+	definedSources[p.String()] = source //canonical, as searched by sourceOf()
+	return defined.GetAndLock(name)
+}
+
+//withTimeout limits ctx to the timeout of the source
+func (e sourceEntry) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.timeout > 0 {
+		return context.WithTimeout(ctx, e.timeout)
+	}
+	return ctx, func() {}
+}
+
+//keys lists the keys of the source within its timeout and the context
+//ok=false when the source cannot list its keys
+func (e sourceEntry) keys(ctx context.Context) (keys []string, ok bool, err error) {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	if legacy, isLegacy := e.source.(legacySource); isLegacy {
+		keys, err := legacy.IContextSource.Keys(ctx, "")
+		if errors.Is(err, ErrNotSupported) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, true, &SourceError{Source: sourceName(e.source), Err: fmt.Errorf("cannot list keys: %w", err)}
+		}
+		return keys, true, nil
+	}
+	keysSource, isKeysSource := e.source.(IKeysSource)
+	if !isKeysSource {
+		return nil, false, nil
+	}
+	v, _, err := e.call(ctx, "", func() (interface{}, bool) {
+		return keysSource.Keys(), true
+	})
+	if err != nil {
+		return nil, true, err
+	}
+	return v.([]string), true, nil
+}
+
+//get calls the source within its timeout and the context
+//an ISource call continues in the background when it does not complete in time
+func (e sourceEntry) get(ctx context.Context, name string) (interface{}, bool, error) {
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	if legacy, ok := e.source.(legacySource); ok {
+		//context source handles the context itself
+		v, ok, err := legacy.IContextSource.Get(ctx, name)
+		if err != nil {
+			return nil, false, &SourceError{Source: sourceName(e.source), Key: name, Err: err}
+		}
+		return v, ok, nil
+	}
+	return e.call(ctx, name, func() (interface{}, bool) {
+		return e.source.Get(name)
+	})
+}
+
+//namedValue is the result of INamedSource.GetNamed()
+type namedValue struct {
+	named string
+	value interface{}
+}
+
+//getNamed calls the INamedSource of the entry within its timeout and the context
+func (e sourceEntry) getNamed(ctx context.Context, name string) (string, interface{}, bool, error) {
+	namedSource := namedSourceOf(e.source)
+	ctx, cancel := e.withTimeout(ctx)
+	defer cancel()
+	v, ok, err := e.call(ctx, name, func() (interface{}, bool) {
+		named, value, ok := namedSource.GetNamed(name)
+		return namedValue{named: named, value: value}, ok
+	})
+	if err != nil || !ok {
+		return "", nil, false, err
+	}
+	item := v.(namedValue)
+	return item.named, item.value, true, nil
+}
+
+//call get and stop waiting for it when ctx is done
+func (e sourceEntry) call(ctx context.Context, name string, get func() (interface{}, bool)) (interface{}, bool, error) {
+	if ctx.Done() == nil {
+		//cannot be cancelled, call directly
+		v, ok := get()
+		return v, ok, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, false, &SourceError{Source: sourceName(e.source), Key: name, Err: err}
+	}
+	type result struct {
+		value interface{}
+		ok    bool
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, ok := get()
+		done <- result{value: v, ok: ok}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.ok, nil
+	case <-ctx.Done():
+		return nil, false, &SourceError{Source: sourceName(e.source), Key: name, Err: ctx.Err()}
+	}
+}
 
 //template must be a struct
-func GetStruct(name string, tmpl interface{}) (interface{}, error) {
-	value, ok := Get(name)
-	if !ok {
-		return nil, fmt.Errorf("%s not defined", name)
+//or nil to use the struct type registered with SetDefaultStruct()
+func GetStruct(name string, tmpl interface{}, opts ...Option) (interface{}, error) {
+	if tmpl == nil {
+		t, ok := registeredType(name)
+		if !ok {
+			return nil, fmt.Errorf("%s has no template and no registered struct type", name)
+		}
+		tmpl = reflect.Zero(t).Interface()
 	}
 	tmplType := reflect.TypeOf(tmpl)
 	if tmplType.Kind() == reflect.Ptr {
@@ -146,51 +398,52 @@ func GetStruct(name string, tmpl interface{}) (interface{}, error) {
 	if tmplType.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("%s template is %v != struct", name, tmplType)
 	}
-	newStructPtrValue := reflect.New(tmplType)
-	jsonValue, err := json.Marshal(value)
+	value, err := getAs(name, tmplType, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("%s value cannot encode to JSON: %v", name, err)
-	}
-	if err := json.Unmarshal(jsonValue, newStructPtrValue.Interface()); err != nil {
-		return nil, fmt.Errorf("%s value cannot decode into %v: %v", name, tmplType, err)
-	}
-	if validator, ok := newStructPtrValue.Interface().(IValidator); ok {
-		if err := validator.Validate(); err != nil {
-			return nil, fmt.Errorf("%s invalid: %v", name, err)
-		}
+		return nil, err
 	}
 	if reflect.TypeOf(tmpl).Kind() == reflect.Ptr {
-		return newStructPtrValue.Interface(), nil
+		ptrValue := reflect.New(tmplType)
+		ptrValue.Elem().Set(reflect.ValueOf(value))
+		return ptrValue.Interface(), nil
 	}
-	return newStructPtrValue.Elem().Interface(), nil
+	return value, nil
 } //GetStruct()
 
+//GetNamed is same as LookupNamed() but returns ok=false when not defined or not valid
 func GetNamed(name string) (string, interface{}, bool) {
-	value, ok := Get(name)
-	if !ok {
-		return "", nil, false //name not defined
-	}
-	if obj, ok := value.(map[string]interface{}); ok {
-		if len(obj) != 1 {
-			return "", nil, false //not exactly one named item
-		}
-		for named, value := range obj {
-			return named, value, true
+	named, value, err := LookupNamed(name)
+	if err != nil {
+		if !errors.Is(err, ErrNotDefined) {
+			log.Errorf("%v", err)
 		}
+		return "", nil, false
 	}
-	return "", nil, false //name value is not an object
+	return named, value, true
 } //GetNamed()
 
 //Get named config into a struct
 //templates must be named structs to get type of and parse value into struct
-func GetNamedStruct(name string, templates map[string]interface{}) (string, interface{}, error) {
-	named, value, ok := GetNamed(name)
-	if !ok {
-		return "", nil, fmt.Errorf("%s is not defined", name)
+func GetNamedStruct(name string, templates map[string]interface{}, opts ...Option) (string, interface{}, error) {
+	//for JSONSchema(), once for these templates and not after Freeze()
+	key, err := templatesKey(name, templates)
+	if err != nil {
+		return "", nil, err
 	}
+	if _, ok := namedTemplates.Load(key); !ok && !Frozen() {
+		if err := registerNamed(name, templates, logger.GetCaller(2)); err != nil {
+			return "", nil, err
+		}
+		namedTemplates.Store(key, true)
+	}
+	named, value, err := LookupNamed(name)
+	if err != nil {
+		return "", nil, err
+	}
+	namedName := name + "." + quoteSegment(named)
 	tmpl, ok := templates[named]
 	if !ok {
-		return "", nil, fmt.Errorf("unknown %s.%s (no template, expecting %s)", name, named, strings.Join(names(templates), "|"))
+		return "", nil, fmt.Errorf("unknown %s (no template, expecting %s)", namedName, strings.Join(names(templates), "|"))
 	}
 	tmplType := reflect.TypeOf(tmpl)
 	if tmplType.Kind() == reflect.Ptr {
@@ -199,23 +452,16 @@ func GetNamedStruct(name string, templates map[string]interface{}) (string, inte
 	if tmplType.Kind() != reflect.Struct {
 		return "", nil, fmt.Errorf("%s template[%s] is %v != struct", name, named, tmplType)
 	}
-	newStructPtrValue := reflect.New(tmplType)
-	jsonValue, err := json.Marshal(value)
+	namedValue, err := bind(namedName, value, tmplType, opts...)
 	if err != nil {
-		return "", nil, fmt.Errorf("%s.%s value cannot encode to JSON: %v", name, named, err)
-	}
-	if err := json.Unmarshal(jsonValue, newStructPtrValue.Interface()); err != nil {
-		return "", nil, fmt.Errorf("%s.%s value cannot decode into %v: %v", name, named, tmplType, err)
-	}
-	if validator, ok := newStructPtrValue.Interface().(IValidator); ok {
-		if err := validator.Validate(); err != nil {
-			return "", nil, fmt.Errorf("%s.%s invalid: %v", name, named, err)
-		}
+		return "", nil, err
 	}
 	if reflect.TypeOf(tmpl).Kind() == reflect.Ptr {
-		return named, newStructPtrValue.Interface(), nil
+		ptrValue := reflect.New(tmplType)
+		ptrValue.Elem().Set(reflect.ValueOf(namedValue))
+		return named, ptrValue.Interface(), nil
 	}
-	return named, newStructPtrValue.Elem().Interface(), nil
+	return named, namedValue, nil
 } //GetNamedStruct()
 
 func names(items map[string]interface{}) []string {
@@ -1,48 +1,133 @@
 package configfile
 
 import (
+	"bytes"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/stewelarend/config"
 	"gopkg.in/yaml.v2"
 )
 
+var (
+	schemaMutex sync.Mutex
+	schema      config.Schema
+)
+
+//SetSchema sets a schema to check all files added after this call, e.g.:
+//	configfile.SetSchema(config.JSONSchema())
+//set nil to stop checking
+func SetSchema(s config.Schema) {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+	schema = s
+}
+
+//Add a config file as a source
+//it is checked against the schema if one was set with SetSchema()
 func Add(filename string) error {
-	f, err := os.Open(filename)
+	schemaMutex.Lock()
+	s := schema
+	schemaMutex.Unlock()
+	return AddWithSchema(filename, s)
+}
+
+//AddWithSchema adds a config file as a source after checking it against the schema
+//a file that does not match is not added and the error is a *SchemaError
+//each file is checked as one layer of the config, so it does not have to
+//contain the required values, use Check() after adding all files for those
+func AddWithSchema(filename string, s config.Schema) error {
+	data, content, err := load(filename)
 	if err != nil {
-		return fmt.Errorf("cannot open file(%s): %v", filename, err)
+		return err
+	}
+	if s != nil {
+		if errs := s.Check(data, config.CheckPartial()); len(errs) > 0 {
+			return schemaError(filename, content, errs)
+		}
+	}
+	return config.AddSource(config.NewValues(filename, data))
+}
+
+//Check checks the resolved config, i.e. all files and defaults together,
+//against the schema set with SetSchema(), including the required values
+//call it after adding all files, the error is config.ValidationErrors
+//text values, e.g. from the environment, are accepted as numbers or booleans
+func Check() error {
+	schemaMutex.Lock()
+	s := schema
+	schemaMutex.Unlock()
+	if s == nil {
+		return nil
+	}
+	if errs := s.Check(config.Resolved(), config.CheckText()); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+//Load reads a config file without adding it as a source
+func Load(filename string) (map[string]interface{}, error) {
+	data, _, err := load(filename)
+	return data, err
+}
+
+func load(filename string) (map[string]interface{}, []byte, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open file(%s): %v", filename, err)
 	}
-	defer f.Close()
 
 	if strings.HasSuffix(filename, ".json") {
 		var data map[string]interface{}
-		if err := json.NewDecoder(f).Decode(&data); err != nil {
-			return fmt.Errorf("cannot read JSON object from file(%s): %v", filename, err)
+		if err := json.NewDecoder(bytes.NewReader(content)).Decode(&data); err != nil {
+			return nil, nil, fmt.Errorf("cannot read JSON object from file(%s): %v", filename, err)
 		}
-		config.AddSource(config.NewValues(filename, data))
-		return nil
+		return data, content, nil
 	}
 
 	if strings.HasSuffix(filename, ".xml") {
 		var data map[string]interface{}
-		if err := xml.NewDecoder(f).Decode(&data); err != nil {
-			return fmt.Errorf("cannot read XML object from file(%s): %v", filename, err)
+		if err := xml.NewDecoder(bytes.NewReader(content)).Decode(&data); err != nil {
+			return nil, nil, fmt.Errorf("cannot read XML object from file(%s): %v", filename, err)
 		}
-		config.AddSource(config.NewValues(filename, data))
-		return nil
+		return data, content, nil
 	}
 
 	if strings.HasSuffix(filename, ".yaml") {
 		var data map[string]interface{}
-		if err := yaml.NewDecoder(f).Decode(&data); err != nil {
-			return fmt.Errorf("cannot read YAML object from file(%s): %v", filename, err)
+		if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(&data); err != nil {
+			return nil, nil, fmt.Errorf("cannot read YAML object from file(%s): %v", filename, err)
 		}
-		config.AddSource(config.NewValues(filename, data))
-		return nil
+		return normalizeYAML(data).(map[string]interface{}), content, nil
+	}
+	return nil, nil, fmt.Errorf("unknown suffix in filename(%s) expecting json|xml|yaml", filename)
+} //load()
+
+//normalizeYAML converts the map[interface{}]interface{} objects that yaml.v2 decodes
+//to map[string]interface{} so that nested names can be retrieved
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			obj[fmt.Sprintf("%v", k)] = normalizeYAML(item)
+		}
+		return obj
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = normalizeYAML(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+		return v
 	}
-	return fmt.Errorf("unknown suffix in filename(%s) expecting json|xml|yaml", filename)
+	return value
 }
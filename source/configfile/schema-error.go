@@ -0,0 +1,241 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/stewelarend/config"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+//SchemaError is returned when a file does not match the schema
+type SchemaError struct {
+	Filename   string
+	Violations []Violation
+}
+
+//Violation is one schema error in a file
+//Line and Column are 1-based, or 0 when the format does not provide locations
+type Violation struct {
+	Path   string //name of the value in the file, e.g. "server.http.port"
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *SchemaError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String(e.Filename)
+	}
+	return fmt.Sprintf("file(%s) does not match schema: %s", e.Filename, strings.Join(msgs, "; "))
+}
+
+//String formats the violation as filename:line:column: path: error
+func (v Violation) String(filename string) string {
+	location := filename
+	if v.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", filename, v.Line, v.Column)
+	}
+	if v.Path == "" {
+		return fmt.Sprintf("%s: %v", location, v.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", location, v.Path, v.Err)
+}
+
+func schemaError(filename string, content []byte, errs config.ValidationErrors) *SchemaError {
+	locations := map[string]location{}
+	switch {
+	case strings.HasSuffix(filename, ".json"):
+		for path, offset := range jsonOffsets(content) {
+			line, column := lineColumn(content, offset)
+			locations[path] = location{line: line, column: column}
+		}
+	case strings.HasSuffix(filename, ".yaml"):
+		locations = yamlLocations(content)
+	}
+	e := &SchemaError{Filename: filename}
+	for _, err := range errs {
+		v := Violation{Path: err.Path, Err: err.Err}
+		if l, ok := nearestLocation(locations, err.Path); ok {
+			v.Line, v.Column = l.line, l.column
+		}
+		e.Violations = append(e.Violations, v)
+	}
+	return e
+}
+
+//location of a value in a file, 1-based
+type location struct {
+	line   int
+	column int
+}
+
+//nearestLocation finds the location of path, or of its parent when path is not in the file,
+//e.g. for a missing required field
+func nearestLocation(locations map[string]location, path string) (location, bool) {
+	segments := splitPath(path)
+	for n := len(segments); n >= 0; n-- {
+		if l, ok := locations[joinSegments(segments[:n])]; ok {
+			return l, true
+		}
+	}
+	return location{}, false
+}
+
+//splitPath splits a path of a violation into its segments:
+//names as parsed by config.ParsePath() and list indexes, e.g. `a."b.c"[1].d`
+//into "a", "b.c", "[1]" and "d"
+func splitPath(path string) []string {
+	segments := []string{}
+	for i := 0; i < len(path); {
+		start := i
+		switch path[i] {
+		case '.':
+			i++
+			continue
+		case '[':
+			for i < len(path) && path[i] != ']' {
+				i++
+			}
+			i++
+			if i > len(path) {
+				i = len(path)
+			}
+			segments = append(segments, path[start:i])
+			continue
+		case '"':
+			for i++; i < len(path) && path[i] != '"'; i++ {
+				if path[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		default:
+			for ; i < len(path) && path[i] != '.' && path[i] != '['; i++ {
+				if path[i] == '\\' {
+					i++
+				}
+			}
+		}
+		if i > len(path) {
+			i = len(path)
+		}
+		if p, err := config.ParsePath(path[start:i]); err == nil && len(p) == 1 {
+			segments = append(segments, p[0])
+		} else {
+			segments = append(segments, path[start:i])
+		}
+	}
+	return segments
+}
+
+//joinSegments is the path of segments as used in locations
+func joinSegments(segments []string) string {
+	path := ""
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]") {
+			path += segment
+			continue
+		}
+		if path != "" {
+			path += "."
+		}
+		path += config.NewPath(segment).String()
+	}
+	return path
+}
+
+//yamlLocations returns the location of each value in a YAML document by path,
+//the location of an object field is where its key starts
+func yamlLocations(content []byte) map[string]location {
+	locations := map[string]location{}
+	var doc yaml3.Node
+	if err := yaml3.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return locations
+	}
+	var walk func(path string, node *yaml3.Node)
+	walk = func(path string, node *yaml3.Node) {
+		if _, ok := locations[path]; !ok {
+			locations[path] = location{line: node.Line, column: node.Column}
+		}
+		switch node.Kind {
+		case yaml3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				itemPath := joinSegments([]string{key.Value})
+				if path != "" {
+					itemPath = path + "." + itemPath
+				}
+				locations[itemPath] = location{line: key.Line, column: key.Column}
+				walk(itemPath, node.Content[i+1])
+			}
+		case yaml3.SequenceNode:
+			for i, item := range node.Content {
+				walk(fmt.Sprintf("%s[%d]", path, i), item)
+			}
+		}
+	}
+	walk("", doc.Content[0])
+	return locations
+} //yamlLocations()
+
+//jsonOffsets returns the offset of each value in a JSON document by path,
+//the offset of an object field is where its key starts
+func jsonOffsets(content []byte) map[string]int64 {
+	offsets := map[string]int64{}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	//skipSeparators finds the start of the next token
+	skipSeparators := func(offset int64) int64 {
+		for offset < int64(len(content)) && strings.ContainsRune(" \t\r\n,:", rune(content[offset])) {
+			offset++
+		}
+		return offset
+	}
+	var walk func(path string) error
+	walk = func(path string) error {
+		offsets[path] = skipSeparators(dec.InputOffset())
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'):
+			for dec.More() {
+				keyOffset := skipSeparators(dec.InputOffset())
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				itemPath := config.NewPath(fmt.Sprintf("%v", key)).String()
+				if path != "" {
+					itemPath = path + "." + itemPath
+				}
+				if err := walk(itemPath); err != nil {
+					return err
+				}
+				offsets[itemPath] = keyOffset
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	walk("")
+	return offsets
+} //jsonOffsets()
+
+func lineColumn(content []byte, offset int64) (int, int) {
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -15,6 +15,10 @@ func init() {
 
 type envSource struct{}
 
+func (e envSource) String() string {
+	return "env"
+}
+
 func (e envSource) Get(name string) (interface{}, bool) {
 	s := os.Getenv(name)
 	log.Debugf("Get(%s)=(%T)\"%s\"", name, s, s)
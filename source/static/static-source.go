@@ -5,6 +5,7 @@ import (
 )
 
 //add a static value to config
-func Add(value map[string]interface{}) {
-	config.AddSource(config.NewValues("static", value))
+//fails with config.ErrFrozen after config.Freeze()
+func Add(value map[string]interface{}) error {
+	return config.AddSource(config.NewValues("static", value))
 }
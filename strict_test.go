@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/configfile"
+)
+
+func TestStrict(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "strict.json")
+	if err := os.WriteFile(filename, []byte(`{"strict":{"http":{"address":"myhost","prot":9000,"extra":1}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := configfile.Add(filename); err != nil {
+		t.Fatalf("failed to add file: %v", err)
+	}
+	//extra is known from defaults, only prot is unknown
+	config.SetDefault("strict.http.extra", 0)
+
+	//lenient by default
+	if _, err := config.GetStruct("strict.http", httpServerConfig{}); err != nil {
+		t.Fatalf("lenient failed: %v", err)
+	}
+
+	//strict per call
+	_, err := config.GetStruct("strict.http", httpServerConfig{}, config.WithStrict(true))
+	var unknownErr *config.UnknownKeyError
+	if !errors.As(err, &unknownErr) || unknownErr.Key != "strict.http.prot" || unknownErr.Source != filename {
+		t.Fatalf("wrong strict error: %v", err)
+	}
+
+	//strict globally, and disabled per call
+	config.SetStrict(true)
+	defer config.SetStrict(false)
+	if _, err := config.Bind[httpServerConfig]("strict.http"); !errors.As(err, &unknownErr) {
+		t.Fatalf("wrong strict error: %v", err)
+	}
+	if _, err := config.Bind[httpServerConfig]("strict.http", config.WithStrict(false)); err != nil {
+		t.Fatalf("lenient failed: %v", err)
+	}
+}
+
+func TestStrictAllKeys(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "strictall.json")
+	if err := os.WriteFile(filename, []byte(`{"strictall":{"http":{"prot":9000,"adress":"myhost","port":-1}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := configfile.Add(filename); err != nil {
+		t.Fatalf("failed to add file: %v", err)
+	}
+	if err := config.Register("strictall.http", httpServerConfig{}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	config.SetStrict(true)
+	defer config.SetStrict(false)
+
+	//every unknown key is reported
+	_, err := config.GetStruct("strictall.http", httpServerConfig{})
+	var errs config.ValidationErrors
+	var unknownErr *config.UnknownKeyError
+	if !errors.As(err, &errs) || len(errs) != 2 || !errors.As(err, &unknownErr) {
+		t.Fatalf("expected two unknown keys, got %v", err)
+	}
+
+	//and Validate() continues after them
+	errs = nil
+	if !errors.As(config.Validate(), &errs) {
+		t.Fatalf("expected validation errors")
+	}
+	msgs := []string{}
+	for _, e := range errs {
+		if strings.HasPrefix(e.Path, "strictall.") {
+			msgs = append(msgs, e.Error())
+		}
+	}
+	expected := []string{
+		"strictall.http.adress: unknown key (in " + filename + ")",
+		"strictall.http.prot: unknown key (in " + filename + ")",
+		"strictall.http: negative port:-1",
+	}
+	if strings.Join(msgs, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("errors:\n%s\nexpected:\n%s", strings.Join(msgs, "\n"), strings.Join(expected, "\n"))
+	}
+}
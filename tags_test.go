@@ -0,0 +1,55 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/static"
+)
+
+type taggedServerConfig struct {
+	Address string          `json:"address" default:"localhost" doc:"Interface address"`
+	Port    int             `json:"port" default:"8000" env:"TAGGED_HTTP_PORT" doc:"TCP port"`
+	Timeout time.Duration   `json:"timeout" default:"30s"`
+	Name    string          `json:"name" required:"true"`
+	TLS     taggedTLSConfig `json:"tls"`
+}
+
+type taggedTLSConfig struct {
+	Enabled bool   `json:"enabled" default:"true"`
+	Cert    string `json:"cert"`
+}
+
+func TestStructTags(t *testing.T) {
+	static.Add(map[string]interface{}{
+		"tagged": map[string]interface{}{
+			"a": map[string]interface{}{"name": "a", "address": "myhost"},
+			"b": map[string]interface{}{"port": 9000},
+			"c": map[string]interface{}{"name": "c", "port": 9000},
+		},
+	})
+
+	//defaults fill missing fields, including nested structs
+	c, err := config.GetStruct("tagged.a", taggedServerConfig{})
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+	if a := c.(taggedServerConfig); a.Address != "myhost" || a.Port != 8000 || a.Timeout != 30*time.Second || a.Name != "a" || !a.TLS.Enabled {
+		t.Fatalf("wrong value: %+v", a)
+	}
+
+	//required field
+	if _, err := config.GetStruct("tagged.b", taggedServerConfig{}); !errors.Is(err, config.ErrNotDefined) {
+		t.Fatalf("missing required field: %v", err)
+	}
+
+	//env overrides the configured value
+	os.Setenv("TAGGED_HTTP_PORT", "9001")
+	defer os.Unsetenv("TAGGED_HTTP_PORT")
+	if c, err := config.Bind[*taggedServerConfig]("tagged.c"); err != nil || c.Port != 9001 {
+		t.Fatalf("env override: %+v, %v", c, err)
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import "context"
+
+//IKeysSource is implemented by sources that can list their keys
+//it is required to report unused keys from the source
+type IKeysSource interface {
+	//Keys returns the names of all leaf values in dotted notation
+	Keys() []string
+}
+
+//UnusedKey is a key supplied by a source that was not used
+type UnusedKey struct {
+	Key    string
+	Source string
+}
+
+//UnusedKeys reports every leaf key supplied by a source that was never resolved
+//with Get() (or any of the getters), or that was shadowed by an earlier source
+//call it after initialisation to detect stale or misspelled config, e.g.:
+//	for _, unused := range config.UnusedKeys() {
+//		log.Errorf("unused config %s in %s", unused.Key, unused.Source)
+//	}
+//sources that cannot list their keys (e.g. env) are not reported, see IKeysSource and IContextSource
+func UnusedKeys() []UnusedKey {
+	unused := []UnusedKey{}
+	for _, e := range sourceList() {
+		keys, ok, err := e.keys(context.Background())
+		if err != nil {
+			log.Errorf("%v", err)
+		}
+		if !ok {
+			continue
+		}
+		name := sourceName(e.source)
+		for _, key := range keys {
+			if _, ok := defined.Get(key); ok && sourceOf(key) == name {
+				continue
+			}
+			unused = append(unused, UnusedKey{Key: key, Source: name})
+		}
+	}
+	return unused
+} //UnusedKeys()
@@ -0,0 +1,50 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stewelarend/config"
+	"github.com/stewelarend/config/source/configfile"
+)
+
+func TestUnusedKeys(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.json")
+	if err := os.WriteFile(first, []byte(`{"unused":{"a":1,"b":{"c":2},"x.y":3}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second := filepath.Join(dir, "second.json")
+	if err := os.WriteFile(second, []byte(`{"unused":{"a":4,"d":5}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range []string{first, second} {
+		if err := configfile.Add(filename); err != nil {
+			t.Fatalf("failed to add file: %v", err)
+		}
+	}
+	if a, ok := config.GetInt("unused.a"); !ok || a != 1 {
+		t.Fatalf("unused.a=%v,%v", a, ok)
+	}
+	if d, ok := config.GetInt("unused.d"); !ok || d != 5 {
+		t.Fatalf("unused.d=%v,%v", d, ok)
+	}
+
+	//other tests use the same sources, only look at these files
+	unused := []config.UnusedKey{}
+	for _, u := range config.UnusedKeys() {
+		if u.Source == first || u.Source == second {
+			unused = append(unused, u)
+		}
+	}
+	expected := []config.UnusedKey{
+		{Key: `unused."x.y"`, Source: first},
+		{Key: "unused.b.c", Source: first},
+		{Key: "unused.a", Source: second}, //shadowed by first
+	}
+	if !reflect.DeepEqual(unused, expected) {
+		t.Fatalf("unused=%+v, expected %+v", unused, expected)
+	}
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+//IContextValidator is an alternative to IValidator for types that want to
+//report the location of problems, e.g. a list item or a nested field
+//path is the full name of the value being validated, e.g. "server.http"
+//return a ValidationErrors (or *ValidationError) with paths under it,
+//any other error is reported at path
+type IContextValidator interface {
+	ValidateContext(path string) error
+}
+
+//Validate resolves and validates every registered config value
+//(see Register() and SetDefaultStruct()) and returns all the problems at once
+//call it at startup to fail fast on bad config, e.g.:
+//	if err := config.Validate(); err != nil {
+//		panic(fmt.Sprintf("invalid config: %v", err))
+//	}
+//the error is ValidationErrors, with decode errors of all fields, unknown keys
+//and conflicting defaults (see DefaultConflict) included
+func Validate() error {
+	errs := ValidationErrors{}
+	for _, name := range registeredNames() {
+		t, _ := registeredType(name)
+		value, err := Lookup(name)
+		if errors.Is(err, ErrNotDefined) {
+			//not configured, but defaults and required tags still apply
+			value, err = nil, nil
+		}
+		if err == nil {
+			_, err = bind(name, value, t, collectErrors())
+		}
+		errs = append(errs, validateErrors(name, err)...)
+	}
+	errs = append(errs, conflictErrors()...)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+} //Validate()
+
+//validateErrors converts an error from bind() to ValidationErrors
+func validateErrors(name string, err error) ValidationErrors {
+	if err == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return ValidationErrors{validationErr}
+	}
+	var unknownErr *UnknownKeyError
+	if errors.As(err, &unknownErr) {
+		withoutKey := *unknownErr
+		withoutKey.Key = ""
+		return ValidationErrors{{Path: unknownErr.Key, Err: &withoutKey}}
+	}
+	var typeErr *TypeError
+	if errors.As(err, &typeErr) {
+		//report the key in the path, not twice
+		withoutKey := *typeErr
+		withoutKey.Key = ""
+		return ValidationErrors{{Path: typeErr.Key, Err: &withoutKey}}
+	}
+	return ValidationErrors{{Path: name, Err: err}}
+}
+
+//validate checks a decoded value and all nested struct, slice and map values
+//with the validate tags of struct fields (see parseRules())
+//and IContextValidator or IValidator (with value or pointer receiver)
+//a value is validated before its fields, so a pointer receiver can set defaults
+//v should be addressable so that pointer receivers can be called
+func validate(path string, v reflect.Value) ValidationErrors {
+	errs := ValidationErrors{}
+	if !v.IsValid() {
+		return errs
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return errs
+		}
+		return validate(path, v.Elem())
+	}
+
+	errs = append(errs, validateValue(path, v)...)
+	if isScalar(v.Type()) {
+		return errs
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, f := range structFields(v.Type()) {
+			fv, ok := fieldValueIfSet(v, f.index)
+			if !ok {
+				continue
+			}
+			fieldPath := joinKey(path, quoteSegment(f.name))
+			for _, err := range checkRules(v, f, fv) {
+				errs = append(errs, &ValidationError{Path: fieldPath, Err: err})
+			}
+			errs = append(errs, validate(fieldPath, fv)...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			errs = append(errs, validate(joinKey(path, fmt.Sprintf("[%d]", i)), v.Index(i))...)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			//map values are not addressable, validate a copy and store it
+			//in case a pointer receiver changed it
+			item := reflect.New(iter.Value().Type()).Elem()
+			item.Set(iter.Value())
+			itemErrs := validate(joinKey(path, quoteSegment(fmt.Sprintf("%v", iter.Key()))), item)
+			errs = append(errs, itemErrs...)
+			v.SetMapIndex(iter.Key(), item)
+		}
+	}
+	return errs
+} //validate()
+
+//validateValue calls the validator of one value, not its fields
+func validateValue(path string, v reflect.Value) ValidationErrors {
+	if !v.CanInterface() {
+		return nil
+	}
+	candidates := []interface{}{v.Interface()}
+	if v.CanAddr() {
+		candidates = append([]interface{}{v.Addr().Interface()}, candidates...)
+	}
+	for _, c := range candidates {
+		if validator, ok := c.(IContextValidator); ok {
+			return validationErrors(path, validator.ValidateContext(path))
+		}
+		if validator, ok := c.(IValidator); ok {
+			return validationErrors(path, validator.Validate())
+		}
+	}
+	return nil
+} //validateValue()
+
+//validationErrors returns the errors from a validator with full paths
+func validationErrors(path string, err error) ValidationErrors {
+	if err == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return ValidationErrors{validationErr}
+	}
+	return ValidationErrors{{Path: path, Err: err}}
+}
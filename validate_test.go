@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stewelarend/config"
+)
+
+type validatedUpstream struct {
+	Host string `json:"host"`
+}
+
+func (u validatedUpstream) Validate() error {
+	if u.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	return nil
+}
+
+type validatedRoute struct {
+	Paths []string `json:"paths"`
+}
+
+func (r *validatedRoute) ValidateContext(path string) error {
+	errs := config.ValidationErrors{}
+	for i, p := range r.Paths {
+		if p == "" || p[0] != '/' {
+			errs = append(errs, &config.ValidationError{Path: fmt.Sprintf("%s.paths[%d]", path, i), Err: fmt.Errorf("must start with /")})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type validatedProxy struct {
+	Default   validatedUpstream            `json:"default"`
+	Upstreams []validatedUpstream          `json:"upstreams"`
+	Routes    map[string]*validatedRoute   `json:"routes"`
+	Backup    *validatedUpstream           `json:"backup"`
+	Named     map[string]validatedUpstream `json:"named"`
+}
+
+func TestValidationErrors(t *testing.T) {
+	config.SetDefault("validated.proxy", map[string]interface{}{
+		"default":   map[string]interface{}{"host": "a"},
+		"upstreams": []interface{}{map[string]interface{}{"host": "b"}, map[string]interface{}{}},
+		"routes":    map[string]interface{}{"api": map[string]interface{}{"paths": []interface{}{"/api", "v1"}}},
+		"backup":    map[string]interface{}{},
+	})
+	_, err := config.Bind[validatedProxy]("validated.proxy")
+	var errs config.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	expected := "validated.proxy.upstreams[1]: missing host; validated.proxy.routes.api.paths[1]: must start with /; validated.proxy.backup: missing host"
+	if err.Error() != expected {
+		t.Fatalf("err=%v\nexpected %s", err, expected)
+	}
+	if errs[0].Path != "validated.proxy.upstreams[1]" {
+		t.Fatalf("wrong path %s", errs[0].Path)
+	}
+
+	//the errors in the list can be matched
+	notDefined := config.ValidationErrors{{Path: "a", Err: fmt.Errorf("x")}, {Path: "b", Err: config.ErrNotDefined}}
+	if !notDefined.Is(config.ErrNotDefined) || notDefined.Is(config.ErrInvalidName) {
+		t.Fatalf("Is() failed")
+	}
+	var validationErr *config.ValidationError
+	if !notDefined.As(&validationErr) || validationErr.Path != "a" {
+		t.Fatalf("As() failed")
+	}
+}
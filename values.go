@@ -3,7 +3,7 @@ package config
 import (
 	"fmt"
 	"regexp"
-	"strings"
+	"sort"
 	"sync"
 )
 
@@ -27,8 +27,9 @@ func NewValues(name string, value map[string]interface{}) *values {
 		locked: false,
 	}
 	for fieldName, fieldValue := range value {
-		if err := v.Set(fieldName, fieldValue); err != nil {
-			panic(fmt.Errorf("failed to set init %s.%s: %v", v.name, fieldName, err))
+		//field names are literal keys, e.g. "api.example.com" is one key, not a nested name
+		if err := v.setPath(Path{fieldName}, fieldValue); err != nil {
+			panic(fmt.Errorf("failed to set init %s.%s: %v", v.name, quoteSegment(fieldName), err))
 		}
 	}
 	return v
@@ -36,63 +37,76 @@ func NewValues(name string, value map[string]interface{}) *values {
 
 //names may only consist only of alpha-numerics with '_' and '-' in the middle of the name
 //names use '.' to nest
+//segments that do not match this pattern must be quoted, see ParsePath()
 const namePattern = `[a-zA-Z]([a-zA-Z0-9_-]*[a-zA-Z0-9])*`
 
 var nameRegex = regexp.MustCompile("^" + namePattern + "$")
 
+//String is the name of the values, used to identify values added as a source
+func (v *values) String() string {
+	return v.name
+}
+
+//subName is the full name of a nested values inside v
+func (v *values) subName(segment string) string {
+	if v.name == "" {
+		return quoteSegment(segment)
+	}
+	return v.name + "." + quoteSegment(segment)
+}
+
 //Set a named config value
 //name may be dot-notation for nesting
 func (v *values) Set(name string, value interface{}) error {
+	p, err := ParsePath(name)
+	if err != nil {
+		return err
+	}
+	return v.setPath(p, value)
+}
+
+func (v *values) setPath(p Path, value interface{}) error {
 	v.Lock()
 	defer v.Unlock()
-	nameParts := strings.SplitN(name, ".", 2)
-	if len(nameParts) == 0 {
+	if len(p) == 0 {
 		return fmt.Errorf("missing name")
 	}
-
-	if !nameRegex.MatchString(nameParts[0]) {
-		return fmt.Errorf("invalid name \"%s\" in \"%s\"", nameParts[0], name)
+	if p[0] == "" {
+		return fmt.Errorf("invalid empty name in %s", v.name)
 	}
 
-	if len(nameParts) == 1 {
+	if len(p) == 1 {
 		if v.locked {
-			return fmt.Errorf("%s.%s is locked, cannot change", v.name, nameParts[0])
+			return fmt.Errorf("%s is locked, cannot change", v.subName(p[0]))
 		}
-		sub, ok := v.value[name]
+		sub, ok := v.value[p[0]]
 		if ok {
-			return fmt.Errorf("%s.%s=(%T)%v cannot be set to (%T)%v", v.name, nameParts[0], sub, sub, value, value)
+			return fmt.Errorf("%s=(%T)%v cannot be set to (%T)%v", v.subName(p[0]), sub, sub, value, value)
 		}
 
 		if obj, ok := value.(map[string]interface{}); ok {
-			sub := NewValues(nameParts[0], nil)
-			if v.name != "" {
-				sub.name = v.name + "." + nameParts[0]
-			}
+			sub := NewValues(v.subName(p[0]), nil)
 			for fieldName, fieldValue := range obj {
-				if err := sub.Set(fieldName, fieldValue); err != nil {
-					return fmt.Errorf("failed to set %s.%s: %v", v.name, fieldName, err)
+				if err := sub.setPath(Path{fieldName}, fieldValue); err != nil {
+					return fmt.Errorf("failed to set %s: %v", sub.subName(fieldName), err)
 				}
 			}
-			v.value[nameParts[0]] = sub
+			v.value[p[0]] = sub
 			return nil
 		}
-		v.value[nameParts[0]] = value
+		v.value[p[0]] = value
 		return nil
 	}
 
-	if sub, ok := v.value[nameParts[0]]; ok {
-		if subValues := sub.(*values); ok {
-			return subValues.Set(nameParts[1], value)
+	if sub, ok := v.value[p[0]]; ok {
+		if subValues, ok := sub.(*values); ok {
+			return subValues.setPath(p[1:], value)
 		}
-		return fmt.Errorf("%s.%s=(%T)%v cannot set %s=(%T)%v", v.name, nameParts[0], sub, sub, nameParts[1], value, value)
-	}
-	subValues := NewValues(nameParts[0], nil)
-	if v.name != "" {
-		subValues.name = v.name + "." + nameParts[0]
+		return fmt.Errorf("%s=(%T)%v cannot set %s=(%T)%v", v.subName(p[0]), sub, sub, p[1:], value, value)
 	}
-
-	v.value[nameParts[0]] = subValues
-	return subValues.Set(nameParts[1], value)
+	subValues := NewValues(v.subName(p[0]), nil)
+	v.value[p[0]] = subValues
+	return subValues.setPath(p[1:], value)
 }
 
 //Get a named config value
@@ -110,21 +124,24 @@ func (v *values) GetAndLock(name string) (value interface{}, err error) {
 }
 
 func (v *values) GetWithLock(name string, setLocked bool) (value interface{}, err error) {
+	p, err := ParsePath(name)
+	if err != nil {
+		return nil, err
+	}
+	return v.getPath(p, setLocked)
+}
+
+func (v *values) getPath(p Path, setLocked bool) (value interface{}, err error) {
 	v.Lock()
 	defer v.Unlock()
-	nameParts := strings.SplitN(name, ".", 2)
-	if len(nameParts) == 0 {
+	if len(p) == 0 {
 		return nil, fmt.Errorf("missing name")
 	}
 
-	if !nameRegex.MatchString(nameParts[0]) {
-		return nil, fmt.Errorf("invalid name \"%s\" in \"%s\"", nameParts[0], name)
-	}
-
-	if len(nameParts) == 1 {
-		sub, ok := v.value[name]
+	if len(p) == 1 {
+		sub, ok := v.value[p[0]]
 		if !ok {
-			return nil, fmt.Errorf("%s.%s is not defined", v.name, nameParts[0])
+			return nil, fmt.Errorf("%s is not defined", v.subName(p[0]))
 		}
 		if subValues, ok := sub.(*values); ok {
 			if setLocked {
@@ -135,14 +152,31 @@ func (v *values) GetWithLock(name string, setLocked bool) (value interface{}, er
 		return sub, nil
 	}
 
-	if sub, ok := v.value[nameParts[0]]; ok {
+	if sub, ok := v.value[p[0]]; ok {
 		if subValues, ok := sub.(*values); ok {
-			return subValues.GetWithLock(nameParts[1], setLocked)
+			return subValues.getPath(p[1:], setLocked)
 		}
-		return nil, fmt.Errorf("cannot find \"%s\" inside value (%T)%v", nameParts[1], sub, sub)
-		//return sub, nil
+		return nil, fmt.Errorf("cannot find \"%s\" inside value (%T)%v", p[1:], sub, sub)
 	}
-	return nil, fmt.Errorf("%s.%s is not defined", v.name, nameParts[0])
+	return nil, fmt.Errorf("%s is not defined", v.subName(p[0]))
+}
+
+//lockedParent is true when the object that contains p is locked,
+//so that p cannot be set anymore
+func (v *values) lockedParent(p Path) bool {
+	v.Lock()
+	defer v.Unlock()
+	if len(p) < 2 {
+		return false
+	}
+	sub, ok := v.value[p[0]].(*values)
+	if !ok {
+		return false
+	}
+	if len(p) == 2 {
+		return sub.locked
+	}
+	return sub.lockedParent(p[1:])
 }
 
 func (v *values) Value() map[string]interface{} {
@@ -169,39 +203,61 @@ func (v *values) SetLock() {
 func (v *values) Merge(b *values) {
 	if b != nil {
 		for bn, bv := range b.value {
-			v.Del(bn)
-			if err := v.Set(bn, bv); err != nil {
-				panic(fmt.Errorf("cannot merge v(%s) %s=(%T)%+v: %v", v.name, bn, bv, bv, err))
+			v.delPath(Path{bn})
+			if err := v.setPath(Path{bn}, bv); err != nil {
+				panic(fmt.Errorf("cannot merge v(%s) %s=(%T)%+v: %v", v.name, quoteSegment(bn), bv, bv, err))
 			}
 		}
 	}
 }
 
 func (v *values) Del(name string) error {
+	p, err := ParsePath(name)
+	if err != nil {
+		return err
+	}
+	return v.delPath(p)
+}
+
+func (v *values) delPath(p Path) error {
 	v.Lock()
 	defer v.Unlock()
-	nameParts := strings.SplitN(name, ".", 2)
-	if len(nameParts) == 0 {
+	if len(p) == 0 {
 		return fmt.Errorf("missing name")
 	}
 
-	if !nameRegex.MatchString(nameParts[0]) {
-		return fmt.Errorf("invalid name \"%s\" in \"%s\"", nameParts[0], name)
-	}
-
-	if len(nameParts) == 1 {
+	if len(p) == 1 {
 		if v.locked {
-			return fmt.Errorf("%s.%s is locked, cannot delete", v.name, nameParts[0])
+			return fmt.Errorf("%s is locked, cannot delete", v.subName(p[0]))
 		}
-		delete(v.value, name)
+		delete(v.value, p[0])
 		return nil
 	}
 
-	if sub, ok := v.value[nameParts[0]]; ok {
-		if subValues := sub.(*values); ok {
-			return subValues.Del(nameParts[1])
+	if sub, ok := v.value[p[0]]; ok {
+		if subValues, ok := sub.(*values); ok {
+			return subValues.delPath(p[1:])
 		}
-		return fmt.Errorf("%s.%s=(%T)%v cannot del(%s)", v.name, nameParts[0], sub, sub, nameParts[1])
+		return fmt.Errorf("%s=(%T)%v cannot del(%s)", v.subName(p[0]), sub, sub, p[1:])
 	}
 	return nil
 }
+
+//Keys returns the names of all leaf values in dotted notation, sorted
+//objects are not leaf values, their fields are
+func (v *values) Keys() []string {
+	v.Lock()
+	defer v.Unlock()
+	keys := []string{}
+	for n, value := range v.value {
+		if subValues, ok := value.(*values); ok {
+			for _, subKey := range subValues.Keys() {
+				keys = append(keys, quoteSegment(n)+"."+subKey)
+			}
+			continue
+		}
+		keys = append(keys, quoteSegment(n))
+	}
+	sort.Strings(keys)
+	return keys
+}